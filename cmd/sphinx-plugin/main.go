@@ -26,6 +26,36 @@ var (
 	wanIP            string
 	position         string
 	buildChainServer string
+
+	ethBaseFeeMultiplier float64
+	ethMaxTipGwei        float64
+	ethMaxFeeGwei        float64
+
+	evmStuckBlocks     uint64
+	evmStuckTimeout    int64
+	evmFeeBumpPercent  int64
+	evmMaxReplaceTimes int
+
+	utxoStrategy   string
+	btcAddressType string
+
+	filMaxFee        float64
+	tronMaxFeeLimit  float64
+	tronTxExpiration int
+
+	solPriorityFeePercentile int
+	solMaxComputeUnitPrice   uint64
+	solTxVersion             string
+	solLookupTables          string
+
+	nonceParallelism     int
+	broadcastParallelism int
+	syncParallelism      int
+
+	retryMaxAttempts int
+	retryMaxAge      int64
+
+	journalPath string
 )
 
 func main() {
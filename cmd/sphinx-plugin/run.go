@@ -41,6 +41,36 @@ var runCmd = &cli.Command{
 			WanIP:            wanIP,
 			Position:         position,
 			BuildChainServer: buildChainServer,
+
+			EthBaseFeeMultiplier: ethBaseFeeMultiplier,
+			EthMaxTipGwei:        ethMaxTipGwei,
+			EthMaxFeeGwei:        ethMaxFeeGwei,
+
+			EvmStuckBlocks:     evmStuckBlocks,
+			EvmStuckTimeout:    evmStuckTimeout,
+			EvmFeeBumpPercent:  evmFeeBumpPercent,
+			EvmMaxReplaceTimes: evmMaxReplaceTimes,
+
+			UTXOStrategy:   utxoStrategy,
+			BTCAddressType: btcAddressType,
+
+			FilMaxFee:        filMaxFee,
+			TronMaxFeeLimit:  tronMaxFeeLimit,
+			TronTxExpiration: tronTxExpiration,
+
+			SolPriorityFeePercentile: solPriorityFeePercentile,
+			SolMaxComputeUnitPrice:   solMaxComputeUnitPrice,
+			SolTxVersion:             solTxVersion,
+			SolLookupTables:          solLookupTables,
+
+			NonceParallelism:     nonceParallelism,
+			BroadcastParallelism: broadcastParallelism,
+			SyncParallelism:      syncParallelism,
+
+			RetryMaxAttempts: retryMaxAttempts,
+			RetryMaxAge:      retryMaxAge,
+
+			JournalPath: journalPath,
 		})
 		err := logger.Init(
 			logger.DebugLevel,
@@ -149,6 +179,183 @@ var runCmd = &cli.Command{
 			Value:       "",
 			Destination: &buildChainServer,
 		},
+		// eip-1559 fee policy
+		&cli.Float64Flag{
+			Name:        "eth-base-fee-multiplier",
+			Usage:       "max fee per gas = base fee * multiplier + tip",
+			EnvVars:     []string{"ENV_ETH_BASE_FEE_MULTIPLIER"},
+			Value:       2,
+			DefaultText: "2",
+			Destination: &ethBaseFeeMultiplier,
+		},
+		&cli.Float64Flag{
+			Name:        "eth-max-tip-gwei",
+			Usage:       "upper limit of the priority fee per gas(gwei), 0 means no limit",
+			EnvVars:     []string{"ENV_ETH_MAX_TIP_GWEI"},
+			Value:       0,
+			Destination: &ethMaxTipGwei,
+		},
+		&cli.Float64Flag{
+			Name:        "eth-max-fee-gwei",
+			Usage:       "upper limit of the max fee per gas(gwei), 0 means no limit",
+			EnvVars:     []string{"ENV_ETH_MAX_FEE_GWEI"},
+			Value:       0,
+			Destination: &ethMaxFeeGwei,
+		},
+		// stuck transaction policy of eth and bsc
+		&cli.Uint64Flag{
+			Name:        "evm-stuck-blocks",
+			Usage:       "replace the pending transaction after blocks, 0 means disable",
+			EnvVars:     []string{"ENV_EVM_STUCK_BLOCKS"},
+			Value:       0,
+			Destination: &evmStuckBlocks,
+		},
+		&cli.Int64Flag{
+			Name:        "evm-stuck-timeout",
+			Usage:       "replace the pending transaction after seconds, 0 means disable",
+			EnvVars:     []string{"ENV_EVM_STUCK_TIMEOUT"},
+			Value:       600,
+			DefaultText: "600",
+			Destination: &evmStuckTimeout,
+		},
+		&cli.Int64Flag{
+			Name:        "evm-fee-bump-percent",
+			Usage:       "fee bump percent of the replacement transaction, at least 10",
+			EnvVars:     []string{"ENV_EVM_FEE_BUMP_PERCENT"},
+			Value:       20,
+			DefaultText: "20",
+			Destination: &evmFeeBumpPercent,
+		},
+		&cli.IntFlag{
+			Name:        "evm-max-replace-times",
+			Usage:       "max replace times of one transaction",
+			EnvVars:     []string{"ENV_EVM_MAX_REPLACE_TIMES"},
+			Value:       3,
+			DefaultText: "3",
+			Destination: &evmMaxReplaceTimes,
+		},
+		// utxo selection of btc and depinc
+		&cli.StringFlag{
+			Name:        "utxo-strategy",
+			Usage:       "utxo selection strategy support auto|bnb|knapsack|largest-first",
+			EnvVars:     []string{"ENV_UTXO_STRATEGY"},
+			Value:       "auto",
+			DefaultText: "auto",
+			Destination: &utxoStrategy,
+		},
+		&cli.StringFlag{
+			Name:        "btc-address-type",
+			Usage:       "address type of the new bitcoin account support p2wpkh|p2pkh",
+			EnvVars:     []string{"ENV_BTC_ADDRESS_TYPE"},
+			Value:       "p2wpkh",
+			DefaultText: "p2wpkh",
+			Destination: &btcAddressType,
+		},
+		&cli.Float64Flag{
+			Name:        "fil-max-fee",
+			Usage:       "upper limit of the filecoin message fee(FIL), 0 means use the lotus default",
+			EnvVars:     []string{"ENV_FIL_MAX_FEE"},
+			Value:       0,
+			Destination: &filMaxFee,
+		},
+		&cli.Float64Flag{
+			Name:        "tron-max-fee-limit",
+			Usage:       "upper limit of the trc20 fee limit(TRX)",
+			EnvVars:     []string{"ENV_TRON_MAX_FEE_LIMIT"},
+			Value:       100,
+			DefaultText: "100",
+			Destination: &tronMaxFeeLimit,
+		},
+		&cli.IntFlag{
+			Name:        "tron-tx-expiration",
+			Usage:       "expiration window of the tron transaction from built(second)",
+			EnvVars:     []string{"ENV_TRON_TX_EXPIRATION"},
+			Value:       600,
+			DefaultText: "600",
+			Destination: &tronTxExpiration,
+		},
+		// priority fee policy of solana
+		&cli.IntFlag{
+			Name:        "sol-priority-fee-percentile",
+			Usage:       "percentile of the recent prioritization fees as the compute unit price",
+			EnvVars:     []string{"ENV_SOL_PRIORITY_FEE_PERCENTILE"},
+			Value:       75,
+			DefaultText: "75",
+			Destination: &solPriorityFeePercentile,
+		},
+		&cli.Uint64Flag{
+			Name:        "sol-max-compute-unit-price",
+			Usage:       "upper limit of the compute unit price(micro-lamports)",
+			EnvVars:     []string{"ENV_SOL_MAX_COMPUTE_UNIT_PRICE"},
+			Value:       1_000_000,
+			DefaultText: "1000000",
+			Destination: &solMaxComputeUnitPrice,
+		},
+		&cli.StringFlag{
+			Name:        "sol-tx-version",
+			Usage:       "version of the built solana transaction, legacy or 0",
+			EnvVars:     []string{"ENV_SOL_TX_VERSION"},
+			Value:       "legacy",
+			DefaultText: "legacy",
+			Destination: &solTxVersion,
+		},
+		&cli.StringFlag{
+			Name:        "sol-lookup-tables",
+			Usage:       "address lookup tables of the v0 solana transaction, split by comma",
+			EnvVars:     []string{"ENV_SOL_LOOKUP_TABLES"},
+			Value:       "",
+			Destination: &solLookupTables,
+		},
+		// parallelism of the transaction tasks
+		&cli.IntFlag{
+			Name:        "nonce-parallelism",
+			Usage:       "transactions handled concurrently by the nonce task",
+			EnvVars:     []string{"ENV_NONCE_PARALLELISM"},
+			Value:       4,
+			DefaultText: "4",
+			Destination: &nonceParallelism,
+		},
+		&cli.IntFlag{
+			Name:        "broadcast-parallelism",
+			Usage:       "transactions handled concurrently by the broadcast task",
+			EnvVars:     []string{"ENV_BROADCAST_PARALLELISM"},
+			Value:       4,
+			DefaultText: "4",
+			Destination: &broadcastParallelism,
+		},
+		&cli.IntFlag{
+			Name:        "sync-parallelism",
+			Usage:       "transactions handled concurrently by the sync task",
+			EnvVars:     []string{"ENV_SYNC_PARALLELISM"},
+			Value:       4,
+			DefaultText: "4",
+			Destination: &syncParallelism,
+		},
+		// retry budget of the failed transaction
+		&cli.IntFlag{
+			Name:        "retry-max-attempts",
+			Usage:       "max attempts of the failed transaction before it is failed, 0 is unlimited",
+			EnvVars:     []string{"ENV_RETRY_MAX_ATTEMPTS"},
+			Value:       30,
+			DefaultText: "30",
+			Destination: &retryMaxAttempts,
+		},
+		&cli.Int64Flag{
+			Name:        "retry-max-age",
+			Usage:       "max age(second) from the first failed attempt before the transaction is failed, 0 is unlimited",
+			EnvVars:     []string{"ENV_RETRY_MAX_AGE"},
+			Value:       0,
+			DefaultText: "0",
+			Destination: &retryMaxAge,
+		},
+		// local journal of the in-flight transactions
+		&cli.StringFlag{
+			Name:        "journal-path",
+			Usage:       "file of the local journal of the in-flight transactions, empty is disabled",
+			EnvVars:     []string{"ENV_JOURNAL_PATH"},
+			Value:       "",
+			Destination: &journalPath,
+		},
 	},
 	Action: func(c *cli.Context) error {
 		log.Infof(
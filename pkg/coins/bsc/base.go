@@ -1,13 +1,20 @@
 package bsc
 
 import (
-	"strings"
+	"context"
+	"fmt"
+	"math/big"
 
+	bc_client "github.com/NpoolPlatform/build-chain/pkg/client/v1"
+	"github.com/NpoolPlatform/go-service-framework/pkg/logger"
+	"github.com/NpoolPlatform/libent-cruder/pkg/cruder"
 	v1 "github.com/NpoolPlatform/message/npool/basetypes/v1"
+	proto "github.com/NpoolPlatform/message/npool/build-chain/v1"
 	"github.com/NpoolPlatform/message/npool/sphinxplugin"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/register"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/env"
+	"github.com/ethereum/go-ethereum/common"
 )
 
 const (
@@ -17,25 +24,21 @@ const (
 	ChainUnitExp        = 18
 	ChainNativeCoinName = "binancecoin"
 	ChainID             = "56"
+
+	// TransferGasLimit the gas of the bnb transfer
+	TransferGasLimit = uint64(21_000)
+	// GasTolerance the estimated gas of the bep20 transfer is raised by it
+	GasTolerance = 1.25
 )
 
 var (
-	gasTooLow   = `intrinsic gas too low`
-	fundsTooLow = `insufficient funds for gas * price + value`
-	nonceToLow  = `nonce too low`
-	stopErrMsg  = []string{gasTooLow, fundsTooLow, nonceToLow}
-
-	GetContract = func(chainet int64, token *coins.TokenInfo) string {
-		switch chainet {
-		case 56:
-			return token.OfficialContract
-		default:
-			contract, ok := env.LookupEnv(env.ENVCONTRACT)
-			if !ok {
-				panic(env.ErrENVContractNotFound)
-			}
-			return contract
-		}
+	gasTooLow     = `intrinsic gas too low`
+	fundsTooLow   = `insufficient funds for gas * price + value`
+	nonceToLow    = `nonce too low`
+	errorMessages = []register.ErrorMessage{
+		{Message: gasTooLow, Kind: register.ErrorKindPermanent},
+		{Message: fundsTooLow, Kind: register.ErrorKindInsufficientFunds},
+		{Message: nonceToLow, Kind: register.ErrorKindPermanent},
 	}
 
 	bscTokenList = []*coins.TokenInfo{
@@ -52,7 +55,7 @@ func init() {
 		token.ChainNativeUnit = ChainNativeUnit
 		token.ChainAtomicUnit = ChainAtomicUnit
 		token.ChainUnitExp = ChainUnitExp
-		token.GasType = v1.GasType_GasUnsupported
+		token.GasType = v1.GasType_DynamicGas
 		token.ChainID = ChainID
 		token.ChainNickname = ChainType.String()
 		token.ChainNativeCoinName = ChainNativeCoinName
@@ -62,17 +65,83 @@ func init() {
 		token.Contract = token.OfficialContract
 		register.RegisteTokenInfo(token)
 	}
+
+	register.RegisteTokenNetHandler(sphinxplugin.CoinType_CoinTypetbinancecoin, netHandle)
 }
 
-func TxFailErr(err error) bool {
-	if err == nil {
-		return false
+// netHandle set the test contracts of the bep20 tokens which deployed by build-chain
+func netHandle(tokenInfos []*coins.TokenInfo) error {
+	ctx := context.Background()
+	bcServer, ok := env.LookupEnv(env.ENVBUIILDCHIANSERVER)
+	if !ok {
+		return env.ErrENVBuildChainServerNotFound
+	}
+
+	bcConn, bcConnErr := bc_client.NewClientConn(ctx, bcServer)
+	if bcConnErr != nil {
+		logger.Sugar().Error(bcConnErr)
+		return fmt.Errorf("connect server failed, %v", bcConnErr)
+	}
+	defer bcConn.Close()
+	bep20List, err := bcConn.GetTokenInfos(ctx, &proto.GetTokenInfosRequest{
+		Conds: &proto.Conds{
+			TokenType: &v1.StringVal{
+				Op:    cruder.EQ,
+				Value: string(coins.Bep20),
+			},
+		},
+	})
+	if err != nil {
+		logger.Sugar().Error(err)
+		return fmt.Errorf("failed to get token infos from build-chain, err: %v", err)
+	}
+
+	officialContractMap := make(map[string]*coins.TokenInfo)
+	for _, v := range tokenInfos {
+		if v.TokenType == coins.Binancecoin {
+			v.DisableRegiste = false
+			continue
+		}
+		officialContractMap[v.OfficialContract] = v
 	}
 
-	for _, v := range stopErrMsg {
-		if strings.Contains(err.Error(), v) {
-			return true
+	for _, info := range bep20List.Infos {
+		if _, ok := officialContractMap[info.OfficialContract]; ok && info.PrivateContract != "" {
+			officialContractMap[info.OfficialContract].DisableRegiste = false
+			officialContractMap[info.OfficialContract].Contract = info.PrivateContract
 		}
 	}
-	return false
+
+	return nil
+}
+
+// Contract the contract of the bep20 token, the test token which is not deployed
+// by build-chain use the ENV_CONTRACT
+func Contract(tokenInfo *coins.TokenInfo) (string, error) {
+	contract := tokenInfo.Contract
+	if contract == "" && tokenInfo.Net == coins.CoinNetTest {
+		contract, _ = env.LookupEnv(env.ENVCONTRACT)
+	}
+	if !common.IsHexAddress(contract) {
+		return "", fmt.Errorf("contract %v, %v", contract, env.ErrContractInvalid)
+	}
+	return contract, nil
+}
+
+// CheckFunds the bnb balance of the sender must cover the fee and the value
+func CheckFunds(balance, fee, value *big.Int) error {
+	total := big.NewInt(0).Add(fee, value)
+	if balance == nil || balance.Cmp(total) < 0 {
+		return fmt.Errorf("%v, need %v but %v", fundsTooLow, total, balance)
+	}
+	return nil
+}
+
+// ClassifyErr the kind of the node error
+func ClassifyErr(err error) register.ErrorKind {
+	return register.MessageClassifier(errorMessages...)(err)
+}
+
+func TxFailErr(err error) bool {
+	return ClassifyErr(err).Abort()
 }
@@ -0,0 +1,53 @@
+package bsc
+
+import (
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/env"
+	"github.com/test-go/testify/assert"
+)
+
+func TestContract(t *testing.T) {
+	official := "0x55d398326f99059fF775485246999027B3197955"
+	private := "0x91722d81bA5CD2E7f0a5de4eB34510BCF7221721"
+
+	contract, err := Contract(&coins.TokenInfo{Net: coins.CoinNetMain, Contract: official})
+	assert.Nil(t, err)
+	assert.Equal(t, official, contract)
+
+	_, err = Contract(&coins.TokenInfo{Net: coins.CoinNetMain})
+	assert.NotNil(t, err)
+
+	// the test token which is not deployed by build-chain
+	os.Unsetenv(env.ENVCONTRACT)
+	_, err = Contract(&coins.TokenInfo{Net: coins.CoinNetTest})
+	assert.NotNil(t, err)
+
+	os.Setenv(env.ENVCONTRACT, private)
+	defer os.Unsetenv(env.ENVCONTRACT)
+	contract, err = Contract(&coins.TokenInfo{Net: coins.CoinNetTest})
+	assert.Nil(t, err)
+	assert.Equal(t, private, contract)
+
+	// the build-chain contract is preferred
+	contract, err = Contract(&coins.TokenInfo{Net: coins.CoinNetTest, Contract: official})
+	assert.Nil(t, err)
+	assert.Equal(t, official, contract)
+}
+
+func TestCheckFunds(t *testing.T) {
+	fee := big.NewInt(21_000)
+	value := big.NewInt(100)
+
+	assert.Nil(t, CheckFunds(big.NewInt(21_100), fee, value))
+	assert.Nil(t, CheckFunds(big.NewInt(21_000), fee, big.NewInt(0)))
+
+	err := CheckFunds(big.NewInt(21_099), fee, value)
+	assert.NotNil(t, err)
+	assert.True(t, TxFailErr(err))
+
+	assert.NotNil(t, CheckFunds(nil, fee, value))
+}
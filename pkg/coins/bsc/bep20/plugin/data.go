@@ -0,0 +1,40 @@
+package plugin
+
+import (
+	v1 "github.com/NpoolPlatform/message/npool/basetypes/v1"
+	"github.com/NpoolPlatform/message/npool/sphinxplugin"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/bsc"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/register"
+)
+
+func init() {
+	for i := range bep20tokens {
+		// set chain info
+		bep20tokens[i].ChainType = bsc.ChainType
+		bep20tokens[i].ChainNativeUnit = bsc.ChainNativeUnit
+		bep20tokens[i].ChainAtomicUnit = bsc.ChainAtomicUnit
+		bep20tokens[i].ChainUnitExp = bsc.ChainUnitExp
+		bep20tokens[i].GasType = v1.GasType_DynamicGas
+		bep20tokens[i].ChainID = bsc.ChainID
+		bep20tokens[i].ChainNickname = bsc.ChainType.String()
+		bep20tokens[i].ChainNativeCoinName = bsc.ChainNativeCoinName
+
+		bep20tokens[i].TokenType = coins.Bep20
+		bep20tokens[i].Net = coins.CoinNetMain
+		bep20tokens[i].Waight = 1
+		bep20tokens[i].Contract = bep20tokens[i].OfficialContract
+		bep20tokens[i].CoinType = sphinxplugin.CoinType_CoinTypebinancecoin
+		bep20tokens[i].Name = coins.GenerateName(&bep20tokens[i])
+		register.RegisteTokenInfo(&bep20tokens[i])
+	}
+}
+
+var bep20tokens = []coins.TokenInfo{
+	{OfficialName: "Binance-Peg USD Coin", Decimal: 18, Unit: "USDC", OfficialContract: "0x8AC76a51cc950d9822D68b83fE1Ad97B32Cd580d"},
+	{OfficialName: "Binance-Peg Ethereum Token", Decimal: 18, Unit: "ETH", OfficialContract: "0x2170Ed0880ac9A755fd29B2688956BD959F933F8"},
+	{OfficialName: "Binance-Peg BTCB Token", Decimal: 18, Unit: "BTCB", OfficialContract: "0x7130d2A12B9BCbFAe4f2634d864A1Ee1Ce3Ead9c"},
+	{OfficialName: "Binance-Peg Dai Token", Decimal: 18, Unit: "DAI", OfficialContract: "0x1AF3F329e8BE154074D8769D1FFa4eE058B1DBc3"},
+	{OfficialName: "Wrapped BNB", Decimal: 18, Unit: "WBNB", OfficialContract: "0xbb4CdB9CBd36B01bD1cBaEBF2De08d9173bc095c"},
+	{OfficialName: "PancakeSwap Token", Decimal: 18, Unit: "CAKE", OfficialContract: "0x0E09FaBB73Bd3Ade0a17ECC321fD13a19e81cE82"},
+}
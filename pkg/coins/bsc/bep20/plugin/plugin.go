@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"math"
 	"math/big"
 
 	"github.com/NpoolPlatform/message/npool/sphinxplugin"
@@ -12,9 +11,9 @@ import (
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/bsc"
 	bsc_plugin "github.com/NpoolPlatform/sphinx-plugin/pkg/coins/bsc/plugin"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/register"
-	"github.com/NpoolPlatform/sphinx-plugin/pkg/log"
 	plugin_types "github.com/NpoolPlatform/sphinx-plugin/pkg/types"
 
+	ethereum "github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
@@ -27,10 +26,15 @@ func init() {
 		register.OpGetBalance,
 		walletBalance,
 	)
+	register.RegisteTokenHandler(
+		coins.Bep20,
+		register.OpEstimateGas,
+		estimateGas,
+	)
 	register.RegisteTokenHandler(
 		coins.Bep20,
 		register.OpPreSign,
-		bsc_plugin.PreSign,
+		preSign,
 	)
 	register.RegisteTokenHandler(
 		coins.Bep20,
@@ -42,13 +46,18 @@ func init() {
 		register.OpSyncTx,
 		bsc_plugin.SyncTxState,
 	)
+	register.RegisteTokenHandler(
+		coins.Bep20,
+		register.OpPreSignRelease,
+		bsc_plugin.ReleasePreSign,
+	)
 
-	err := register.RegisteAbortFuncErr(sphinxplugin.CoinType_CoinTypebinanceusd, bsc.TxFailErr)
+	err := register.RegisteErrorClassifier(sphinxplugin.CoinType_CoinTypebinanceusd, bsc.ClassifyErr)
 	if err != nil {
 		panic(err)
 	}
 
-	err = register.RegisteAbortFuncErr(sphinxplugin.CoinType_CoinTypetbinanceusd, bsc.TxFailErr)
+	err = register.RegisteErrorClassifier(sphinxplugin.CoinType_CoinTypetbinanceusd, bsc.ClassifyErr)
 	if err != nil {
 		panic(err)
 	}
@@ -71,21 +80,7 @@ func walletBalance(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (
 		return nil, err
 	}
 
-	balance, ok := big.NewFloat(0).SetString(bl.String())
-	if !ok {
-		return nil, errors.New("convert balance string to float64 error")
-	}
-
-	balance.Quo(balance, big.NewFloat(math.Pow10(tokenInfo.Decimal)))
-	f, exact := balance.Float64()
-	if exact != big.Exact {
-		log.Warnf("wallet balance transfer warning balance from->to %v-%v", balance.String(), f)
-	}
-
-	wbResp := &plugin_types.WalletBalanceResponse{
-		Balance:    f,
-		BalanceStr: balance.String(),
-	}
+	wbResp := plugin_types.NewWalletBalanceResponse(plugin_types.NewAmountFromAtomic(bl, tokenInfo.Decimal))
 	out, err = json.Marshal(wbResp)
 
 	return out, err
@@ -96,12 +91,12 @@ func _walletBalance(ctx context.Context, addr string, tokenInfo *coins.TokenInfo
 	var err error
 	client := bsc.Client()
 
+	contract, err := bsc.Contract(tokenInfo)
+	if err != nil {
+		return nil, err
+	}
+
 	err = client.WithClient(ctx, func(ctx context.Context, c *ethclient.Client) (bool, error) {
-		chainID, err := c.ChainID(ctx)
-		if err != nil || chainID == nil {
-			return true, err
-		}
-		contract := bsc.GetContract(chainID.Int64(), tokenInfo)
 		ret, err = bep20Balance(ctx, contract, addr, c)
 		if err != nil || ret == nil {
 			return true, err
@@ -112,6 +107,54 @@ func _walletBalance(ctx context.Context, addr string, tokenInfo *coins.TokenInfo
 	return ret, err
 }
 
+// preSign the gas limit is estimated by the transfer of the token contract
+func preSign(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []byte, err error) {
+	contract, err := bsc.Contract(tokenInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	return bsc_plugin.BuildPreSign(ctx, in, tokenInfo, contract, func(ctx context.Context, cli *ethclient.Client, from, to common.Address, amount *big.Int) (uint64, error) {
+		return estimateTransferGas(ctx, cli, common.HexToAddress(contract), from, to, amount)
+	})
+}
+
+// estimateGas the mock transfer carries zero token, it can be sent from the account without balance
+func estimateGas(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []byte, err error) {
+	contract, err := bsc.Contract(tokenInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	return bsc_plugin.BuildEstimateGas(ctx, in, func(ctx context.Context, cli *ethclient.Client, from, to common.Address, amount *big.Int) (uint64, error) {
+		return estimateTransferGas(ctx, cli, common.HexToAddress(contract), from, to, amount)
+	})
+}
+
+func estimateTransferGas(ctx context.Context, cli *ethclient.Client, contract, from, to common.Address, amount *big.Int) (uint64, error) {
+	_abi, err := BEP20TokenMetaData.GetAbi()
+	if err != nil {
+		return 0, err
+	}
+
+	input, err := _abi.Pack("transfer", to, amount)
+	if err != nil {
+		return 0, err
+	}
+
+	gasLimit, err := cli.EstimateGas(ctx, ethereum.CallMsg{
+		From:  from,
+		To:    &contract,
+		Value: big.NewInt(0),
+		Data:  input,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(float64(gasLimit) * bsc.GasTolerance), nil
+}
+
 func bep20Balance(ctx context.Context, contract, addr string, client bind.ContractBackend) (*big.Int, error) {
 	if !common.IsHexAddress(contract) {
 		return nil, ErrContractAddrInvalid
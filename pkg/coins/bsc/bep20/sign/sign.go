@@ -4,8 +4,6 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"errors"
-	"math"
 	"math/big"
 	"strings"
 
@@ -13,6 +11,7 @@ import (
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/bsc"
 	bscSign "github.com/NpoolPlatform/sphinx-plugin/pkg/coins/bsc/sign"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/register"
+	ct "github.com/NpoolPlatform/sphinx-plugin/pkg/types"
 
 	"github.com/NpoolPlatform/go-service-framework/pkg/oss"
 	busd "github.com/NpoolPlatform/sphinx-plugin/pkg/coins/bsc/bep20/plugin"
@@ -37,7 +36,7 @@ func init() {
 }
 
 func CreateBep20Account(ctx context.Context, in []byte, token *coins.TokenInfo) (out []byte, err error) {
-	s3KeyPrxfix := coins.S3KeyPrxfixMap[token.Name]
+	s3KeyPrxfix := coins.GetS3KeyPrxfix(token)
 	return bscSign.CreateAccount(ctx, s3KeyPrxfix, in)
 }
 
@@ -48,7 +47,7 @@ func Bep20Msg(ctx context.Context, in []byte, token *coins.TokenInfo) (out []byt
 		return in, err
 	}
 
-	s3KeyPrxfix := coins.S3KeyPrxfixMap[token.Name]
+	s3KeyPrxfix := coins.GetS3KeyPrxfix(token)
 	pk, err := oss.GetObject(ctx, s3KeyPrxfix+preSignData.From, true)
 	if err != nil {
 		return in, err
@@ -59,18 +58,20 @@ func Bep20Msg(ctx context.Context, in []byte, token *coins.TokenInfo) (out []byt
 		return in, err
 	}
 
+	if preSignData.Tx != nil {
+		return bscSign.SignReplaceTx(privateKey, preSignData)
+	}
+
 	_abi, err := abi.JSON(strings.NewReader(busd.BEP20TokenABI))
 	if err != nil {
 		return in, err
 	}
 
-	amount := big.NewFloat(preSignData.Value)
-	amount.Mul(amount, big.NewFloat(math.Pow10(token.Decimal)))
-
-	amountBig, ok := big.NewInt(0).SetString(amount.Text('f', 0), 10)
-	if !ok {
-		return in, errors.New("invalid busd amount")
+	amount, err := ct.ParseAmount(preSignData.Amount, preSignData.Value, token.Decimal)
+	if err != nil {
+		return in, err
 	}
+	amountBig := amount.BigInt()
 
 	input, err := _abi.Pack(
 		"transfer",
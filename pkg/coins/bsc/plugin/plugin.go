@@ -5,17 +5,21 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"math"
+	"fmt"
 	"math/big"
+	"time"
 
 	"github.com/NpoolPlatform/message/npool/sphinxplugin"
+	"github.com/NpoolPlatform/message/npool/sphinxproxy"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/eth"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/env"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/log"
 
 	bsc "github.com/NpoolPlatform/sphinx-plugin/pkg/coins/bsc"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/register"
 	ct "github.com/NpoolPlatform/sphinx-plugin/pkg/types"
+	ethereum "github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
@@ -30,6 +34,11 @@ func init() {
 		register.OpGetBalance,
 		walletBalance,
 	)
+	register.RegisteTokenHandler(
+		coins.Binancecoin,
+		register.OpEstimateGas,
+		estimateGas,
+	)
 	register.RegisteTokenHandler(
 		coins.Binancecoin,
 		register.OpPreSign,
@@ -45,13 +54,18 @@ func init() {
 		register.OpSyncTx,
 		SyncTxState,
 	)
+	register.RegisteTokenHandler(
+		coins.Binancecoin,
+		register.OpPreSignRelease,
+		ReleasePreSign,
+	)
 
-	err := register.RegisteAbortFuncErr(sphinxplugin.CoinType_CoinTypebinancecoin, bsc.TxFailErr)
+	err := register.RegisteErrorClassifier(sphinxplugin.CoinType_CoinTypebinancecoin, bsc.ClassifyErr)
 	if err != nil {
 		panic(err)
 	}
 
-	err = register.RegisteAbortFuncErr(sphinxplugin.CoinType_CoinTypetbinancecoin, bsc.TxFailErr)
+	err = register.RegisteErrorClassifier(sphinxplugin.CoinType_CoinTypetbinancecoin, bsc.ClassifyErr)
 	if err != nil {
 		panic(err)
 	}
@@ -81,27 +95,43 @@ func walletBalance(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (
 		return nil, err
 	}
 
-	balance, ok := big.NewFloat(0).SetString(bl.String())
-	if !ok {
-		return nil, errors.New("convert balance string to float64 error")
-	}
-
-	balance.Quo(balance, big.NewFloat(math.Pow10(tokenInfo.Decimal)))
-	f, exact := balance.Float64()
-	if exact != big.Exact {
-		log.Warnf("wallet balance transfer warning balance from->to %v-%v", balance.String(), f)
-	}
-
-	wbResp := &ct.WalletBalanceResponse{
-		Balance:    f,
-		BalanceStr: balance.String(),
-	}
+	wbResp := ct.NewWalletBalanceResponse(ct.NewAmountFromAtomic(bl, tokenInfo.Decimal))
 	out, err = json.Marshal(wbResp)
 
 	return out, err
 }
 
+// GasEstimator estimate the gas limit of the transfer
+type GasEstimator func(ctx context.Context, cli *ethclient.Client, from, to common.Address, amount *big.Int) (uint64, error)
+
 func PreSign(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []byte, err error) {
+	return BuildPreSign(ctx, in, tokenInfo, "", transferGas)
+}
+
+// transferGas only the eoa recipient can use the transfer gas, the contract
+// recipient may run code in its fallback
+func transferGas(ctx context.Context, cli *ethclient.Client, from, to common.Address, amount *big.Int) (uint64, error) {
+	gasLimit, err := cli.EstimateGas(ctx, ethereum.CallMsg{
+		From:  from,
+		To:    &to,
+		Value: amount,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	toCode, err := cli.CodeAt(ctx, to, nil)
+	if err != nil {
+		return 0, err
+	}
+	if len(toCode) == 0 {
+		return bsc.TransferGasLimit, nil
+	}
+	return uint64(float64(gasLimit) * bsc.GasTolerance), nil
+}
+
+// BuildPreSign build the pre sign data, the contract is empty for the bnb transfer
+func BuildPreSign(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo, contract string, estimateGas GasEstimator) (out []byte, err error) {
 	baseInfo := &ct.BaseInfo{}
 	err = json.Unmarshal(in, baseInfo)
 	if err != nil {
@@ -112,38 +142,131 @@ func PreSign(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []
 		return nil, env.ErrEVNCoinNetValue
 	}
 
-	if !common.IsHexAddress(baseInfo.From) {
+	if !common.IsHexAddress(baseInfo.From) || !common.IsHexAddress(baseInfo.To) {
 		return nil, env.ErrAddressInvalid
 	}
 
+	amount, err := baseInfo.GetAmount(tokenInfo.Decimal)
+	if err != nil {
+		return nil, err
+	}
+	amountBig := amount.BigInt()
+
+	// the bep20 transfer carries no bnb
+	value := big.NewInt(0)
+	if contract == "" {
+		value = amountBig
+	}
+
 	client := bsc.Client()
 
-	var chainID *big.Int
+	var (
+		chainID  *big.Int
+		gasPrice *big.Int
+		gasLimit uint64
+		balance  *big.Int
+	)
 	err = client.WithClient(ctx, func(ctx context.Context, cli *ethclient.Client) (bool, error) {
-		chainID, err = cli.NetworkID(ctx)
+		chainID, err = cli.ChainID(ctx)
 		if err != nil || chainID == nil {
 			return true, err
 		}
-		return false, err
-	})
-	if err != nil {
-		return nil, err
-	}
 
-	var nonce uint64
-	err = client.WithClient(ctx, func(ctx context.Context, cli *ethclient.Client) (bool, error) {
-		nonce, err = cli.PendingNonceAt(ctx, common.HexToAddress(baseInfo.From))
+		gasPrice, err = cli.SuggestGasPrice(ctx)
+		if err != nil || gasPrice == nil {
+			return true, err
+		}
+
+		gasLimit, err = estimateGas(
+			ctx,
+			cli,
+			common.HexToAddress(baseInfo.From),
+			common.HexToAddress(baseInfo.To),
+			amountBig,
+		)
 		if err != nil {
 			return true, err
 		}
+
+		balance, err = cli.BalanceAt(ctx, common.HexToAddress(baseInfo.From), nil)
+		if err != nil || balance == nil {
+			return true, err
+		}
 		return false, err
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	var gasPrice *big.Int
+	estimateFee := big.NewInt(0).Mul(gasPrice, big.NewInt(int64(gasLimit)))
+	if err := bsc.CheckFunds(balance, estimateFee, value); err != nil {
+		return nil, err
+	}
+
+	log.Infof("from: %v, estimate fee + value: %v + %v, balance: %v",
+		baseInfo.From,
+		eth.ToEth(estimateFee),
+		eth.ToEth(value),
+		eth.ToEth(balance),
+	)
+
+	nonce, err := eth.ReserveNonce(ctx, client, chainID, baseInfo.From)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &bsc.PreSignData{
+		ChainID:    chainID.Int64(),
+		Nonce:      nonce,
+		GasPrice:   gasPrice.Int64(),
+		From:       baseInfo.From,
+		To:         baseInfo.To,
+		Value:      baseInfo.Value,
+		Amount:     amount.String(),
+		ContractID: contract,
+		GasLimit:   int64(gasLimit),
+	}
+
+	return json.Marshal(info)
+}
+
+func estimateGas(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []byte, err error) {
+	return BuildEstimateGas(ctx, in, transferGas)
+}
+
+// the accounts of the representative transfer, the request carries no accounts and
+// the token contracts reject the transfer to the zero address
+var (
+	mockFrom = common.HexToAddress("0x5754284f345afc66a98fbB0a0Afe71e0F007B949")
+	mockTo   = common.HexToAddress("0x91722d81bA5CD2E7f0a5de4eB34510BCF7221721")
+)
+
+// BuildEstimateGas estimate the fee of the transfer between the mock accounts
+func BuildEstimateGas(ctx context.Context, in []byte, estimateGas GasEstimator) (out []byte, err error) {
+	esGasReq := &sphinxproxy.GetEstimateGasRequest{}
+	err = json.Unmarshal(in, esGasReq)
+	if err != nil {
+		return nil, err
+	}
+
+	client := bsc.Client()
+
+	var (
+		gasLimit    uint64
+		blockHeight uint64
+		gasPrice    *big.Int
+	)
 	err = client.WithClient(ctx, func(ctx context.Context, cli *ethclient.Client) (bool, error) {
+		blockHeight, err = cli.BlockNumber(ctx)
+		if err != nil {
+			return true, err
+		}
+
+		gasLimit, err = estimateGas(ctx, cli, mockFrom, mockTo, big.NewInt(0))
+		if err != nil {
+			return true, err
+		}
+
 		gasPrice, err = cli.SuggestGasPrice(ctx)
 		if err != nil || gasPrice == nil {
 			return true, err
@@ -154,27 +277,27 @@ func PreSign(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []
 		return nil, err
 	}
 
-	info := &bsc.PreSignData{
-		ChainID:  chainID.Int64(),
-		Nonce:    nonce,
-		GasPrice: gasPrice.Int64(),
-		From:     baseInfo.From,
-		To:       baseInfo.To,
-		Value:    baseInfo.Value,
+	estimateFee := big.NewInt(0).Mul(gasPrice, big.NewInt(int64(gasLimit)))
+
+	esGasResp := &sphinxproxy.GetEstimateGasResponse{
+		GasLimit: fmt.Sprint(gasLimit),
+		GasPrice: gasPrice.String(),
+		Fee:      eth.ToEth(estimateFee).String(),
+		BlockNum: blockHeight,
 	}
+	return json.Marshal(esGasResp)
+}
 
-	switch baseInfo.CoinType {
-	case sphinxplugin.CoinType_CoinTypebinancecoin, sphinxplugin.CoinType_CoinTypetbinancecoin:
-		info.GasLimit = 21_000
-	case sphinxplugin.CoinType_CoinTypebinanceusd,
-		sphinxplugin.CoinType_CoinTypetbinanceusd,
-		sphinxplugin.CoinType_CoinTypeusdtbep20,
-		sphinxplugin.CoinType_CoinTypetusdtbep20:
-		info.ContractID = bsc.GetContract(chainID.Int64(), tokenInfo)
-		info.GasLimit = 300_000
+// ReleasePreSign release the nonce of the pre sign payload which is lost
+func ReleasePreSign(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []byte, err error) {
+	preSignData := &bsc.PreSignData{}
+	err = json.Unmarshal(in, preSignData)
+	if err != nil {
+		return nil, err
 	}
 
-	return json.Marshal(info)
+	eth.Nonces().Release(big.NewInt(preSignData.ChainID), preSignData.From, preSignData.Nonce)
+	return nil, nil
 }
 
 // SendRawTransaction bsc
@@ -192,9 +315,15 @@ func SendRawTransaction(ctx context.Context, in []byte, tokenInfo *coins.TokenIn
 	}
 
 	client := bsc.Client()
+	var blockNum uint64
 	err = client.WithClient(ctx, func(ctx context.Context, c *ethclient.Client) (bool, error) {
-		err = c.SendTransaction(ctx, tx)
-		if err != nil && bsc.TxFailErr(err) {
+		blockNum, err = c.BlockNumber(ctx)
+		if err != nil {
+			return true, err
+		}
+		// the resent transaction already in the pool or on chain is treated as sent
+		err = eth.SendTransaction(ctx, c, tx, signedData.ReplacedTxIDs...)
+		if err != nil && (bsc.TxFailErr(err) || eth.ReplaceUnderpricedErr(err)) {
 			return false, err
 		}
 		if err != nil {
@@ -202,12 +331,25 @@ func SendRawTransaction(ctx context.Context, in []byte, tokenInfo *coins.TokenIn
 		}
 		return false, err
 	})
+	if err != nil && len(signedData.ReplacedTxIDs) > 0 && (bsc.TxFailErr(err) || eth.ReplaceUnderpricedErr(err)) {
+		// the replaced transactions are pending, keep syncing them
+		log.Warnf("replace transaction %v rejected: %v", tx.Hash().Hex(), err)
+		return json.Marshal(eth.RejectedReplacement(signedData.ReplacedTxIDs, blockNum, time.Now()))
+	}
+	if err != nil && bsc.TxFailErr(err) {
+		// the nonce will not be on chain, release it to fill the gap
+		eth.ReleaseNonce(tx)
+	}
 	if err != nil {
 		return nil, err
 	}
 
 	broadcastedData := ct.BroadcastInfo{
-		TxID: tx.Hash().Hex(),
+		TxID:          tx.Hash().Hex(),
+		SignedTx:      signedData.SignedTx,
+		ReplacedTxIDs: signedData.ReplacedTxIDs,
+		BlockNum:      blockNum,
+		BroadcastAt:   time.Now().Unix(),
 	}
 	out, err = json.Marshal(broadcastedData)
 	return out, err
@@ -222,10 +364,14 @@ func SyncTxState(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (ou
 	}
 
 	client := bsc.Client()
-	var isPending bool
+	var (
+		receipt     *types.Receipt
+		replacement *eth.Replacement
+	)
 	err = client.WithClient(ctx, func(ctx context.Context, c *ethclient.Client) (bool, error) {
-		_, isPending, err = c.TransactionByHash(ctx, common.HexToHash(broadcastedData.TxID))
-		if err != nil {
+		// the replacement with the bumped gas price is built when the transaction is stuck
+		receipt, replacement, err = eth.SyncReplaceableTx(ctx, c, broadcastedData, eth.GetStuckPolicy(), time.Now())
+		if err != nil && !errors.Is(err, env.ErrWaitMessageOnChain) {
 			return true, err
 		}
 		return false, err
@@ -233,27 +379,26 @@ func SyncTxState(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (ou
 	if err != nil {
 		return nil, err
 	}
-	if isPending {
-		return nil, env.ErrWaitMessageOnChain
-	}
 
-	var receipt *types.Receipt
-	err = client.WithClient(ctx, func(ctx context.Context, c *ethclient.Client) (bool, error) {
-		receipt, err = c.TransactionReceipt(ctx, common.HexToHash(broadcastedData.TxID))
+	// the stuck transaction is replaced, route the replacement back to sign
+	if replacement != nil {
+		resign, err := json.Marshal(&bsc.PreSignData{
+			From:          replacement.From,
+			ChainID:       replacement.ChainID.Int64(),
+			Nonce:         replacement.Tx.Nonce(),
+			GasPrice:      replacement.Tx.GasPrice().Int64(),
+			GasLimit:      int64(replacement.Tx.Gas()),
+			Tx:            replacement.Tx,
+			ReplacedTxIDs: replacement.ReplacedTxIDs,
+		})
 		if err != nil {
-			return true, err
+			return nil, err
 		}
-		return false, err
-	})
-	if err != nil {
-		return nil, err
-	}
-	if receipt == nil {
-		return nil, env.ErrWaitMessageOnChain
+		return json.Marshal(&ct.SyncResponse{Resign: resign})
 	}
 
 	if receipt.Status == types.ReceiptStatusSuccessful {
-		sResp := &ct.SyncResponse{ExitCode: 0}
+		sResp := &ct.SyncResponse{ExitCode: 0, TxID: receipt.TxHash.Hex()}
 		out, err = json.Marshal(sResp)
 		if err != nil {
 			return nil, err
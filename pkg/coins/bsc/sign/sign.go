@@ -3,10 +3,10 @@ package sign
 import (
 	"bytes"
 	"context"
+	"crypto/ecdsa"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
-	"math"
 	"math/big"
 
 	"github.com/NpoolPlatform/go-service-framework/pkg/oss"
@@ -58,14 +58,16 @@ func Message(ctx context.Context, s3Store string, in []byte, tokenInfo *coins.To
 		return nil, err
 	}
 
-	amount := big.NewFloat(preSignData.Value)
-	amount.Mul(amount, big.NewFloat(math.Pow10(tokenInfo.Decimal)))
+	if preSignData.Tx != nil {
+		return SignReplaceTx(privateKey, preSignData)
+	}
 
-	amountBig, ok := big.NewInt(0).SetString(amount.Text('f', 0), 10)
-	if !ok {
-		return nil, errors.New("invalid bsc amount")
+	amount, err := ct.ParseAmount(preSignData.Amount, preSignData.Value, tokenInfo.Decimal)
+	if err != nil {
+		return nil, err
 	}
 
+	amountBig := amount.BigInt()
 	if amountBig.Cmp(common.Big0) <= 0 {
 		return nil, errors.New("invalid bsc amount")
 	}
@@ -99,6 +101,25 @@ func Message(ctx context.Context, s3Store string, in []byte, tokenInfo *coins.To
 	return out, err
 }
 
+// SignReplaceTx sign the replacement transaction which built by sync
+func SignReplaceTx(privateKey *ecdsa.PrivateKey, preSignData *bsc.PreSignData) ([]byte, error) {
+	signedTx, err := types.SignTx(preSignData.Tx, types.NewLondonSigner(big.NewInt(preSignData.ChainID)), privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	signedTxBuf := bytes.Buffer{}
+	err = signedTx.EncodeRLP(&signedTxBuf)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(bsc.SignedData{
+		SignedTx:      signedTxBuf.Bytes(),
+		ReplacedTxIDs: preSignData.ReplacedTxIDs,
+	})
+}
+
 func CreateAccount(ctx context.Context, s3Store string, in []byte) (out []byte, err error) {
 	privateKey, err := crypto.GenerateKey()
 	if err != nil {
@@ -1,21 +1,29 @@
 package bsc
 
-import "github.com/NpoolPlatform/message/npool/sphinxplugin"
+import (
+	"github.com/NpoolPlatform/message/npool/sphinxplugin"
+	"github.com/ethereum/go-ethereum/core/types"
+)
 
 type PreSignData struct {
 	CoinType   sphinxplugin.CoinType `json:"coin_type"`
 	From       string                `json:"from"`
 	To         string                `json:"to"`
 	Value      float64               `json:"value"`
+	Amount     string                `json:"amount,omitempty"`
 	ChainID    int64                 `json:"chain_id"`
 	Nonce      uint64                `json:"nonce"`
 	GasPrice   int64                 `json:"gas_price"`
 	ContractID string                `json:"contract_id"`
 	GasLimit   int64                 `json:"gas_limit"`
+	// the replacement of the stuck transactions which built by sync, sign it directly
+	Tx            *types.Transaction `json:"tx,omitempty"`
+	ReplacedTxIDs []string           `json:"replaced_tx_ids,omitempty"`
 }
 
 type SignedData struct {
-	SignedTx []byte `json:"signed_tx"`
+	SignedTx      []byte   `json:"signed_tx"`
+	ReplacedTxIDs []string `json:"replaced_tx_ids,omitempty"`
 }
 
 type BroadcastedData struct {
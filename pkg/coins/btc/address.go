@@ -0,0 +1,205 @@
+package btc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/config"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/env"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/bech32"
+)
+
+const (
+	AddressTypeP2PKH  = "p2pkh"
+	AddressTypeP2WPKH = "p2wpkh"
+
+	taprootWitnessVersion = 1
+	taprootProgramLen     = 32
+	bech32mConst          = 0x2bc830a3
+	bech32Charset         = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+)
+
+var ErrAddressTypeInvalid = errors.New("bitcoin address type invalid")
+
+// DefaultAddressType the address type of the new account, native segwit by default
+func DefaultAddressType() string {
+	if envInfo := config.GetENV(); envInfo != nil && envInfo.BTCAddressType != "" {
+		return envInfo.BTCAddressType
+	}
+	return AddressTypeP2WPKH
+}
+
+// NewAddress build the address of the compressed public key
+func NewAddress(pubKey []byte, addressType string, net *chaincfg.Params) (btcutil.Address, error) {
+	switch addressType {
+	case AddressTypeP2PKH:
+		return btcutil.NewAddressPubKeyHash(btcutil.Hash160(pubKey), net)
+	case AddressTypeP2WPKH:
+		return btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(pubKey), net)
+	}
+	return nil, fmt.Errorf("%v: %v", ErrAddressTypeInvalid, addressType)
+}
+
+// AddressTaproot is the witness v1 address encoded by bech32m, it is only used as the recipient
+type AddressTaproot struct {
+	hrp            string
+	witnessProgram [taprootProgramLen]byte
+}
+
+func (a *AddressTaproot) EncodeAddress() string {
+	data, err := bech32.ConvertBits(a.witnessProgram[:], 8, 5, true)
+	if err != nil {
+		return ""
+	}
+	return bech32mEncode(a.hrp, append([]byte{taprootWitnessVersion}, data...))
+}
+
+func (a *AddressTaproot) String() string {
+	return a.EncodeAddress()
+}
+
+func (a *AddressTaproot) ScriptAddress() []byte {
+	return a.witnessProgram[:]
+}
+
+func (a *AddressTaproot) IsForNet(net *chaincfg.Params) bool {
+	return a.hrp == net.Bech32HRPSegwit
+}
+
+// DecodeWalletAddress decode the address which btcutil supported, the taproot address is not supported
+func DecodeWalletAddress(addr string, net *chaincfg.Params) (btcutil.Address, error) {
+	address, err := btcutil.DecodeAddress(addr, net)
+	if err != nil {
+		return nil, addressErr(err)
+	}
+	return address, nil
+}
+
+// DecodeAddress decode the address which btcutil supported and the taproot address
+func DecodeAddress(addr string, net *chaincfg.Params) (btcutil.Address, error) {
+	address, err := btcutil.DecodeAddress(addr, net)
+	if err == nil {
+		return address, nil
+	}
+
+	taproot, _err := decodeTaproot(addr, net)
+	if _err != nil {
+		return nil, addressErr(err)
+	}
+	return taproot, nil
+}
+
+// addressErr the invalid address error of btcutil, the error of btcutil is never formatted,
+// btcutil.UnsupportedWitnessVerError format itself recursively and overflow the stack
+func addressErr(err error) error {
+	var verErr btcutil.UnsupportedWitnessVerError
+	if errors.As(err, &verErr) {
+		return fmt.Errorf("%v: unsupported witness version %d", env.ErrAddressInvalid, byte(verErr))
+	}
+	return fmt.Errorf("%v: %v", env.ErrAddressInvalid, err)
+}
+
+// PayToAddrScript build the output script of the address
+func PayToAddrScript(addr btcutil.Address) ([]byte, error) {
+	if taproot, ok := addr.(*AddressTaproot); ok {
+		return txscript.NewScriptBuilder().
+			AddOp(txscript.OP_1).
+			AddData(taproot.ScriptAddress()).
+			Script()
+	}
+	return txscript.PayToAddrScript(addr)
+}
+
+func decodeTaproot(addr string, net *chaincfg.Params) (*AddressTaproot, error) {
+	if strings.ToLower(addr) != addr && strings.ToUpper(addr) != addr {
+		return nil, errors.New("mixed case address")
+	}
+	addr = strings.ToLower(addr)
+
+	sep := strings.LastIndexByte(addr, '1')
+	if sep < 1 || sep+7 > len(addr) {
+		return nil, errors.New("invalid bech32m address")
+	}
+
+	hrp := addr[:sep]
+	if hrp != net.Bech32HRPSegwit {
+		return nil, errors.New("address is not for the net")
+	}
+
+	values := make([]byte, 0, len(addr)-sep-1)
+	for _, c := range addr[sep+1:] {
+		idx := strings.IndexRune(bech32Charset, c)
+		if idx < 0 {
+			return nil, errors.New("invalid bech32m character")
+		}
+		values = append(values, byte(idx))
+	}
+	if bech32Polymod(append(bech32HrpExpand(hrp), values...)) != bech32mConst {
+		return nil, errors.New("invalid bech32m checksum")
+	}
+
+	data := values[:len(values)-6]
+	if len(data) == 0 || data[0] != taprootWitnessVersion {
+		return nil, errors.New("not taproot address")
+	}
+
+	program, err := bech32.ConvertBits(data[1:], 5, 8, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(program) != taprootProgramLen {
+		return nil, errors.New("invalid taproot witness program")
+	}
+
+	taproot := &AddressTaproot{hrp: hrp}
+	copy(taproot.witnessProgram[:], program)
+	return taproot, nil
+}
+
+func bech32mEncode(hrp string, data []byte) string {
+	values := append(bech32HrpExpand(hrp), data...)
+	values = append(values, make([]byte, 6)...)
+	polymod := bech32Polymod(values) ^ bech32mConst
+
+	bldr := strings.Builder{}
+	bldr.WriteString(hrp)
+	bldr.WriteByte('1')
+	for _, v := range data {
+		bldr.WriteByte(bech32Charset[v])
+	}
+	for i := 0; i < 6; i++ {
+		bldr.WriteByte(bech32Charset[(polymod>>uint(5*(5-i)))&31])
+	}
+	return bldr.String()
+}
+
+func bech32HrpExpand(hrp string) []byte {
+	expanded := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		expanded = append(expanded, hrp[i]>>5)
+	}
+	expanded = append(expanded, 0)
+	for i := 0; i < len(hrp); i++ {
+		expanded = append(expanded, hrp[i]&31)
+	}
+	return expanded
+}
+
+func bech32Polymod(values []byte) uint32 {
+	gen := []uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+	return chk
+}
@@ -0,0 +1,145 @@
+package btc
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/env"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/test-go/testify/assert"
+)
+
+// the valid bech32m strings of BIP-350
+var validBech32m = []string{
+	"A1LQFN3A",
+	"a1lqfn3a",
+	"an83characterlonghumanreadablepartthatcontainsthetheexcludedcharactersbioandnumber11sg7hg6",
+	"abcdef1l7aum6echk45nj3s0wdvt2fg8x9yrzpqzd3ryx",
+	"11llllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllludsr8",
+	"split1checkupstagehandshakeupstreamerranterredcaperredlc445v",
+	"?1v759aa",
+}
+
+// splitBech32 the hrp and the values of the data and the checksum
+func splitBech32(t *testing.T, s string) (string, []byte) {
+	s = strings.ToLower(s)
+	sep := strings.LastIndexByte(s, '1')
+	values := []byte{}
+	for _, c := range s[sep+1:] {
+		idx := strings.IndexRune(bech32Charset, c)
+		assert.True(t, idx >= 0, s)
+		values = append(values, byte(idx))
+	}
+	return s[:sep], values
+}
+
+func TestBech32Polymod(t *testing.T) {
+	for _, s := range validBech32m {
+		hrp, values := splitBech32(t, s)
+		assert.Equal(t, uint32(bech32mConst), bech32Polymod(append(bech32HrpExpand(hrp), values...)), s)
+	}
+
+	// the invalid checksum of BIP-350, the checksum of M1VUXWEZ is calculated with the uppercase hrp
+	for _, s := range []string{"M1VUXWEZ", "a1lqfn3q", "abcdef1l7aum6echk45nj3s0wdvt2fg8x9yrzpqzd3ryy"} {
+		hrp, values := splitBech32(t, s)
+		assert.NotEqual(t, uint32(bech32mConst), bech32Polymod(append(bech32HrpExpand(hrp), values...)), s)
+	}
+}
+
+func TestBech32mEncode(t *testing.T) {
+	for _, s := range validBech32m {
+		hrp, values := splitBech32(t, s)
+		assert.Equal(t, strings.ToLower(s), bech32mEncode(hrp, values[:len(values)-6]))
+	}
+}
+
+func TestDecodeTaproot(t *testing.T) {
+	valid := []struct {
+		address string
+		net     *chaincfg.Params
+		script  string
+	}{
+		{
+			address: "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0",
+			net:     &chaincfg.MainNetParams,
+			script:  "512079be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798",
+		},
+		{
+			address: "tb1pqqqqp399et2xygdj5xreqhjjvcmzhxw4aywxecjdzew6hylgvsesf3hn0c",
+			net:     &chaincfg.TestNet3Params,
+			script:  "5120000000c4a5cad46221b2a187905e5266362b99d5e91c6ce24d165dab93e86433",
+		},
+	}
+	for _, tt := range valid {
+		taproot, err := decodeTaproot(tt.address, tt.net)
+		assert.Nil(t, err, tt.address)
+		assert.Equal(t, tt.address, taproot.EncodeAddress())
+		assert.True(t, taproot.IsForNet(tt.net))
+
+		address, err := DecodeAddress(tt.address, tt.net)
+		assert.Nil(t, err, tt.address)
+		script, err := PayToAddrScript(address)
+		assert.Nil(t, err)
+		assert.Equal(t, tt.script, hex.EncodeToString(script))
+	}
+
+	// the invalid addresses of BIP-350 and the valid segwit addresses which are not taproot
+	invalid := []string{
+		// invalid hrp
+		"tc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vq5zuyut",
+		// bech32 checksum instead of bech32m
+		"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqh2y7hd",
+		"BC1S0XLXVLHEMJA6C4DQV22UAPCTQUPFHLXM9H8Z3K2E72Q4K9HCZ7VQ54WELL",
+		// bech32m checksum of the witness v0
+		"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kemeawh",
+		// invalid character in checksum
+		"bc1p38j9r5y49hruaue7wxjce0updqjuyyx0kh56v8s25huc6995vvpql3jow4",
+		// invalid witness version
+		"BC130XLXVLHEMJA6C4DQV22UAPCTQUPFHLXM9H8Z3K2E72Q4K9HCZ7VQ7ZWS8R",
+		// invalid program length
+		"bc1pw5dgrnzv",
+		"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7v8n0nx0muaewav253zgeav",
+		// mixed case
+		"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqZk5jj0",
+		// zero padding of more than 4 bits
+		"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7v07qwwzcrf",
+		// empty data
+		"bc1gmk9yu",
+		// not taproot
+		"BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4",
+		"bc1pw508d6qejxtdg4y5r3zarvary0c5xw7kw508d6qejxtdg4y5r3zarvary0c5xw7kt5nd6y",
+	}
+	for _, address := range invalid {
+		_, err := decodeTaproot(address, &chaincfg.MainNetParams)
+		assert.NotNil(t, err, address)
+	}
+
+	// the taproot address of the other net
+	_, err := decodeTaproot(valid[1].address, &chaincfg.MainNetParams)
+	assert.NotNil(t, err)
+}
+
+func TestDecodeAddressErr(t *testing.T) {
+	// btcutil return UnsupportedWitnessVerError for the witness v1 address with bech32
+	// checksum, it must be formatted without overflow the stack
+	for _, address := range []string{
+		"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqh2y7hd",
+		"bc1pw508d6qejxtdg4y5r3zarvary0c5xw7kw508d6qejxtdg4y5r3zarvary0c5xw7k7grplx",
+	} {
+		_, err := DecodeAddress(address, &chaincfg.MainNetParams)
+		assert.NotNil(t, err, address)
+		assert.True(t, strings.HasPrefix(err.Error(), env.ErrAddressInvalid.Error()), err.Error())
+
+		_, err = DecodeWalletAddress(address, &chaincfg.MainNetParams)
+		assert.NotNil(t, err, address)
+		assert.True(t, strings.HasPrefix(err.Error(), env.ErrAddressInvalid.Error()), err.Error())
+	}
+
+	_, err := DecodeWalletAddress("bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", &chaincfg.MainNetParams)
+	assert.NotNil(t, err)
+
+	address, err := DecodeWalletAddress("BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4", &chaincfg.MainNetParams)
+	assert.Nil(t, err)
+	assert.Equal(t, "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", address.EncodeAddress())
+}
@@ -2,7 +2,6 @@ package btc
 
 import (
 	"errors"
-	"strings"
 
 	v1 "github.com/NpoolPlatform/message/npool/basetypes/v1"
 	"github.com/NpoolPlatform/message/npool/sphinxplugin"
@@ -10,11 +9,12 @@ import (
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/register"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/env"
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil"
 )
 
 const (
-	// BTCGas 0.00028BTC
-	BTCGas = 0.00028
+	// FallbackFeeRate 20 satoshi per vB, used when estimatesmartfee has no enough data
+	FallbackFeeRate btcutil.Amount = 20_000
 	// DefaultMinConfirms ..
 	DefaultMinConfirms = 6
 	// DefaultMaxConfirms ..
@@ -40,12 +40,12 @@ var ErrWaitMessageOnChainMinConfirms = errors.New("wait message on chain min con
 var (
 	fundsTooLow    = `insufficient balance`
 	listUnspendErr = `list unspent address fail`
-	stopErrMsg     = []string{
-		fundsTooLow,
-		listUnspendErr,
-		env.ErrEVNCoinNetValue.Error(),
-		env.ErrAddressInvalid.Error(),
-		env.ErrAmountInvalid.Error(),
+	errorMessages  = []register.ErrorMessage{
+		{Message: fundsTooLow, Kind: register.ErrorKindInsufficientFunds},
+		{Message: listUnspendErr, Kind: register.ErrorKindPermanent},
+		{Message: env.ErrEVNCoinNetValue.Error(), Kind: register.ErrorKindPermanent},
+		{Message: env.ErrAddressInvalid.Error(), Kind: register.ErrorKindInvalidAddress},
+		{Message: env.ErrAmountInvalid.Error(), Kind: register.ErrorKindPermanent},
 	}
 	bitcoinToken = &coins.TokenInfo{OfficialName: "Bitcoin", Decimal: 8, Unit: "BTC", Name: ChainNativeCoinName, OfficialContract: ChainNativeCoinName, TokenType: coins.Bitcoin}
 )
@@ -68,15 +68,11 @@ func init() {
 	register.RegisteTokenInfo(bitcoinToken)
 }
 
-func TxFailErr(err error) bool {
-	if err == nil {
-		return false
-	}
+// ClassifyErr the kind of the node error
+func ClassifyErr(err error) register.ErrorKind {
+	return register.MessageClassifier(errorMessages...)(err)
+}
 
-	for _, v := range stopErrMsg {
-		if strings.Contains(err.Error(), v) {
-			return true
-		}
-	}
-	return false
+func TxFailErr(err error) bool {
+	return ClassifyErr(err).Abort()
 }
@@ -3,13 +3,15 @@ package plugin
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"strconv"
+	"math/big"
 
 	"github.com/NpoolPlatform/message/npool/sphinxplugin"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/btc"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/register"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/utxo"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/env"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/log"
 	ct "github.com/NpoolPlatform/sphinx-plugin/pkg/types"
@@ -44,12 +46,12 @@ func init() {
 		syncTx,
 	)
 
-	err := register.RegisteAbortFuncErr(sphinxplugin.CoinType_CoinTypebitcoin, btc.TxFailErr)
+	err := register.RegisteErrorClassifier(sphinxplugin.CoinType_CoinTypebitcoin, btc.ClassifyErr)
 	if err != nil {
 		panic(err)
 	}
 
-	err = register.RegisteAbortFuncErr(sphinxplugin.CoinType_CoinTypetbitcoin, btc.TxFailErr)
+	err = register.RegisteErrorClassifier(sphinxplugin.CoinType_CoinTypetbitcoin, btc.ClassifyErr)
 	if err != nil {
 		panic(err)
 	}
@@ -82,7 +84,7 @@ func walletBalance(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (
 		return nil, env.ErrEVNCoinNetValue
 	}
 
-	_addr, err := btcutil.DecodeAddress(info.Address, btc.BTCNetMap[v])
+	_addr, err := btc.DecodeWalletAddress(info.Address, btc.BTCNetMap[v])
 	if err != nil {
 		return nil, err
 	}
@@ -99,23 +101,18 @@ func walletBalance(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (
 		return nil, err
 	}
 
-	accountAmount := float64(0)
+	accountAmount := btcutil.Amount(0)
 	for _, sp := range unspents {
 		if sp.Address == info.Address {
-			// TODO: bug not accuracy, use bigint
-			accountAmount += sp.Amount
+			spAmount, err := btcutil.NewAmount(sp.Amount)
+			if err != nil {
+				return nil, err
+			}
+			accountAmount += spAmount
 		}
 	}
 
-	balance, err := btcutil.NewAmount(accountAmount)
-	if err != nil {
-		return nil, err
-	}
-
-	_out := ct.WalletBalanceResponse{
-		Balance:    balance.ToBTC(),
-		BalanceStr: strconv.FormatFloat(balance.ToUnit(btcutil.AmountBTC), 'f', -int(btcutil.AmountBTC+8), 64), // process reference is ‘balance.String()’
-	}
+	_out := ct.NewWalletBalanceResponse(ct.NewAmountFromAtomic(big.NewInt(int64(accountAmount)), tokenInfo.Decimal))
 
 	return json.Marshal(_out)
 }
@@ -137,19 +134,39 @@ func preSign(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) ([]byte
 	if info.To == "" {
 		return nil, env.ErrAddressInvalid
 	}
-	if info.Value <= 0 {
+	_amount, err := info.GetAmount(tokenInfo.Decimal)
+	if err != nil {
+		return nil, err
+	}
+	if _amount.Sign() <= 0 || !_amount.BigInt().IsInt64() {
 		return nil, env.ErrAmountInvalid
 	}
 
 	var (
 		from   = info.From
 		to     = info.To
-		amount = info.Value
+		amount = btcutil.Amount(_amount.BigInt().Int64())
 	)
 
-	fromAddr, err := btcutil.DecodeAddress(from, btc.BTCNetMap[info.ENV])
+	fromAddr, err := btc.DecodeWalletAddress(from, btc.BTCNetMap[info.ENV])
 	if err != nil {
-		return nil, fmt.Errorf("%v,%v", env.ErrAddressInvalid.Error(), err)
+		return nil, err
+	}
+
+	fromScript, err := txscript.PayToAddrScript(fromAddr)
+	if err != nil {
+		return nil, fmt.Errorf("%v,%v", env.ErrAddressInvalid, err)
+	}
+
+	// the taproot address only supported as the recipient
+	toAddr, err := btc.DecodeAddress(to, btc.BTCNetMap[info.ENV])
+	if err != nil {
+		return nil, err
+	}
+
+	toScript, err := btc.PayToAddrScript(toAddr)
+	if err != nil {
+		return nil, fmt.Errorf("%v,%v", env.ErrAddressInvalid, err)
 	}
 
 	client := btc.Client()
@@ -170,85 +187,76 @@ func preSign(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) ([]byte
 		return nil, err
 	}
 
-	// 构建新的交易
-	msgTx := wire.NewMsgTx(wire.TxVersion)
-
-	// TODO 优化选择合适的 UTXO 减少交易费
-	enoughUTXOAmount := float64(0)
-	// sign and check need this info
-	// btcutil.Amount is alias of int64
-	inputAccount := make([]btcutil.Amount, 0)
-	amountflag := false
-	for _, txIn := range listUnspentResult {
-		txHash, err := chainhash.NewHashFromStr(txIn.TxID)
-		if err != nil {
-			return nil, err
-		}
-		// 构建输入
-		iAmount, err := btcutil.NewAmount(txIn.Amount)
+	var feeResult *btcjson.EstimateSmartFeeResult
+	err = client.WithClient(ctx, func(cli *rpcclient.Client) (bool, error) {
+		feeResult, err = cli.EstimateSmartFee(utxo.DefaultConfTarget, &btcjson.EstimateModeConservative)
 		if err != nil {
-			return nil, fmt.Errorf("%v,%v", env.ErrAmountInvalid.Error(), err)
+			return true, err
 		}
+		return false, err
+	})
+	if err != nil {
+		log.Warnf("estimate smart fee error: %v, use the fallback fee rate", err)
+	}
 
-		inputAccount = append(inputAccount, iAmount)
-		msgTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(txHash, txIn.Vout), nil, nil))
-
-		// 足够的金额
-		enoughUTXOAmount += txIn.Amount
-		if enoughUTXOAmount >= amount+btc.BTCGas {
-			amountflag = true
-			break
-		}
+	utxos, err := utxo.FromListUnspent(listUnspentResult)
+	if err != nil {
+		return nil, fmt.Errorf("%v,%v", env.ErrAmountInvalid.Error(), err)
 	}
 
-	if !amountflag {
+	params := utxo.NewScriptParams(utxo.FeeRateFromEstimate(feeResult, btc.FallbackFeeRate), fromScript, toScript)
+	selection, err := utxo.Select(utxo.Strategy(), utxos, amount, params)
+	if errors.Is(err, utxo.ErrInsufficientFunds) {
 		// TODO: think how to use same error
 		log.Errorf(
-			"insufficient balance: total: %v, transfer: %v, gas: %v",
-			enoughUTXOAmount,
+			"insufficient balance: utxos: %v, transfer: %v, fee rate: %v",
+			len(utxos),
 			amount,
-			btc.BTCGas,
+			params.FeeRate,
 		)
 		return nil, env.ErrInsufficientBalance
 	}
-
-	fromScript, err := txscript.PayToAddrScript(fromAddr)
 	if err != nil {
-		return nil, fmt.Errorf("%v,%v", env.ErrAddressInvalid, err)
+		return nil, err
 	}
+	log.Infof("select utxos for %v: %v", from, selection)
 
-	// 构建输出和找零
-	// BTC 的最小精度是1e-8
-	changeAmount, err := btcutil.NewAmount(enoughUTXOAmount - amount - btc.BTCGas)
-	if err != nil {
-		return nil, fmt.Errorf("%v,%v", env.ErrAmountInvalid, err)
-	}
+	// new transaction
+	msgTx := wire.NewMsgTx(wire.TxVersion)
 
-	if changeAmount.ToBTC() > 0 {
-		msgTx.AddTxOut(wire.NewTxOut(int64(changeAmount), fromScript))
-	}
+	// sign and check need this info
+	// btcutil.Amount is alias of int64
+	inputAccount := make([]btcutil.Amount, 0, len(selection.Inputs))
+	inputScripts := make([][]byte, 0, len(selection.Inputs))
+	for _, txIn := range selection.Inputs {
+		txHash, err := chainhash.NewHashFromStr(txIn.TxID)
+		if err != nil {
+			return nil, err
+		}
 
-	toAddr, err := btcutil.DecodeAddress(to, btc.BTCNetMap[info.ENV])
-	if err != nil {
-		return nil, fmt.Errorf("%v,%v", env.ErrAddressInvalid, err)
-	}
+		pkScript := txIn.PkScript
+		if len(pkScript) == 0 {
+			pkScript = fromScript
+		}
 
-	toScript, err := txscript.PayToAddrScript(toAddr)
-	if err != nil {
-		return nil, fmt.Errorf("%v,%v", env.ErrAddressInvalid, err)
+		inputAccount = append(inputAccount, txIn.Amount)
+		inputScripts = append(inputScripts, pkScript)
+		msgTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(txHash, txIn.Vout), nil, nil))
 	}
 
-	tAccount, err := btcutil.NewAmount(amount)
-	if err != nil {
-		return nil, fmt.Errorf("%v,%v", env.ErrAmountInvalid, err)
+	// 构建输出和找零
+	// change less than the dust limit is given to the fee
+	if selection.Change > 0 {
+		msgTx.AddTxOut(wire.NewTxOut(int64(selection.Change), fromScript))
 	}
 
-	msgTx.AddTxOut(wire.NewTxOut(int64(tAccount), toScript))
+	msgTx.AddTxOut(wire.NewTxOut(int64(amount), toScript))
 
 	_out := btc.SignMsgTx{
 		BaseInfo:        info,
 		PayToAddrScript: fromScript,
 		Amounts:         inputAccount,
+		PkScripts:       inputScripts,
 		MsgTx:           msgTx,
 	}
 
@@ -262,11 +270,15 @@ func broadcast(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out
 		return nil, err
 	}
 
+	// the hash is known before broadcast, the resent transaction is not sent twice
+	_hash := info.TxHash()
 	client := btc.Client()
-	var _hash *chainhash.Hash
 	err = client.WithClient(ctx, func(cli *rpcclient.Client) (bool, error) {
-		_hash, err = cli.SendRawTransaction(info, false)
-		if err != nil || _hash == nil {
+		_, err = cli.SendRawTransaction(info, false)
+		if utxo.AlreadyBroadcastedErr(err) {
+			return false, nil
+		}
+		if err != nil {
 			return true, err
 		}
 		return false, err
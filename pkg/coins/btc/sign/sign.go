@@ -51,15 +51,16 @@ func createAccount(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (
 		return nil, err
 	}
 
-	addressPubKey, err := btcutil.NewAddressPubKey(
+	address, err := btc.NewAddress(
 		wif.PrivKey.PubKey().SerializeCompressed(),
+		btc.DefaultAddressType(),
 		btc.BTCNetMap[info.ENV],
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	addr := addressPubKey.EncodeAddress()
+	addr := address.EncodeAddress()
 
 	_out := ct.NewAccountResponse{
 		Address: addr,
@@ -99,30 +100,61 @@ func signTx(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []b
 		return nil, err
 	}
 
+	// the sighashes must be calculated after all the inputs and outputs are set
+	sigHashes := txscript.NewTxSigHashes(msgTx)
 	for txIdx := range txIns {
-		sig, err := txscript.SignatureScript(
-			msgTx,
-			txIdx,
-			fromScript,
-			txscript.SigHashAll,
-			wif.PrivKey,
-			true,
-		)
-		if err != nil {
-			return nil, err
+		// the payload built by old version has no input scripts
+		pkScript := fromScript
+		if txIdx < len(info.PkScripts) {
+			pkScript = info.PkScripts[txIdx]
 		}
 
-		msgTx.TxIn[txIdx].SignatureScript = sig
+		if txscript.IsPayToWitnessPubKeyHash(pkScript) {
+			witness, err := txscript.WitnessSignature(
+				msgTx,
+				sigHashes,
+				txIdx,
+				int64(amounts[txIdx]),
+				pkScript,
+				txscript.SigHashAll,
+				wif.PrivKey,
+				true,
+			)
+			if err != nil {
+				return nil, err
+			}
+			msgTx.TxIn[txIdx].Witness = witness
+		} else {
+			sig, err := txscript.SignatureScript(
+				msgTx,
+				txIdx,
+				pkScript,
+				txscript.SigHashAll,
+				wif.PrivKey,
+				true,
+			)
+			if err != nil {
+				return nil, err
+			}
+			msgTx.TxIn[txIdx].SignatureScript = sig
+		}
+	}
+
+	for txIdx := range txIns {
+		pkScript := fromScript
+		if txIdx < len(info.PkScripts) {
+			pkScript = info.PkScripts[txIdx]
+		}
 
 		// validate signature
 		flags := txscript.StandardVerifyFlags
 		vm, err := txscript.NewEngine(
-			fromScript,
+			pkScript,
 			msgTx,
 			txIdx,
 			flags,
 			nil,
-			txscript.NewTxSigHashes(msgTx),
+			sigHashes,
 			int64(amounts[txIdx]),
 		)
 		if err != nil {
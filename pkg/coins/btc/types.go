@@ -12,5 +12,7 @@ type SignMsgTx struct {
 	PayToAddrScript []byte
 	// all used utxo amount
 	Amounts []btcutil.Amount
-	MsgTx   *wire.MsgTx
+	// all used utxo script, the p2wpkh input is signed by the witness
+	PkScripts [][]byte
+	MsgTx     *wire.MsgTx
 }
@@ -24,6 +24,7 @@ const (
 
 	Spacemesh TokenType = "spacemesh"
 	Solana    TokenType = "solana"
+	SPL       TokenType = "spl"
 	Bitcoin   TokenType = "bitcoin"
 	Filecoin  TokenType = "filecoin"
 
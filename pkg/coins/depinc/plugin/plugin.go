@@ -3,14 +3,16 @@ package plugin
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"strconv"
+	"math/big"
 
 	"github.com/NpoolPlatform/message/npool/sphinxplugin"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/depinc"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/depinc/depc/rpcclient"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/register"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/utxo"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/env"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/log"
 	ct "github.com/NpoolPlatform/sphinx-plugin/pkg/types"
@@ -44,12 +46,12 @@ func init() {
 		syncTx,
 	)
 
-	err := register.RegisteAbortFuncErr(sphinxplugin.CoinType_CoinTypedepinc, depinc.TxFailErr)
+	err := register.RegisteErrorClassifier(sphinxplugin.CoinType_CoinTypedepinc, depinc.ClassifyErr)
 	if err != nil {
 		panic(err)
 	}
 
-	err = register.RegisteAbortFuncErr(sphinxplugin.CoinType_CoinTypetdepinc, depinc.TxFailErr)
+	err = register.RegisteErrorClassifier(sphinxplugin.CoinType_CoinTypetdepinc, depinc.ClassifyErr)
 	if err != nil {
 		panic(err)
 	}
@@ -99,23 +101,18 @@ func walletBalance(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (
 		return nil, err
 	}
 
-	accountAmount := float64(0)
+	accountAmount := btcutil.Amount(0)
 	for _, sp := range unspents {
 		if sp.Address == info.Address {
-			// TODO: bug not accuracy, use bigint
-			accountAmount += sp.Amount
+			spAmount, err := btcutil.NewAmount(sp.Amount)
+			if err != nil {
+				return nil, err
+			}
+			accountAmount += spAmount
 		}
 	}
 
-	balance, err := btcutil.NewAmount(accountAmount)
-	if err != nil {
-		return nil, err
-	}
-
-	_out := ct.WalletBalanceResponse{
-		Balance:    balance.ToBTC(),
-		BalanceStr: strconv.FormatFloat(balance.ToUnit(btcutil.AmountBTC), 'f', -int(btcutil.AmountBTC+8), 64), // process reference is ‘balance.String()’
-	}
+	_out := ct.NewWalletBalanceResponse(ct.NewAmountFromAtomic(big.NewInt(int64(accountAmount)), tokenInfo.Decimal))
 
 	return json.Marshal(_out)
 }
@@ -137,14 +134,18 @@ func preSign(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) ([]byte
 	if info.To == "" {
 		return nil, env.ErrAddressInvalid
 	}
-	if info.Value <= 0 {
+	_amount, err := info.GetAmount(tokenInfo.Decimal)
+	if err != nil {
+		return nil, err
+	}
+	if _amount.Sign() <= 0 || !_amount.BigInt().IsInt64() {
 		return nil, env.ErrAmountInvalid
 	}
 
 	var (
 		from   = info.From
 		to     = info.To
-		amount = info.Value
+		amount = btcutil.Amount(_amount.BigInt().Int64())
 	)
 
 	fromAddr, err := btcutil.DecodeAddress(from, depinc.DEPCNetMap[info.ENV])
@@ -170,46 +171,54 @@ func preSign(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) ([]byte
 		return nil, err
 	}
 
+	var feeResult *btcjson.EstimateSmartFeeResult
+	err = client.WithClient(ctx, func(cli *rpcclient.Client) (bool, error) {
+		feeResult, err = cli.EstimateSmartFee(utxo.DefaultConfTarget, &btcjson.EstimateModeConservative)
+		if err != nil {
+			return true, err
+		}
+		return false, err
+	})
+	if err != nil {
+		log.Warnf("estimate smart fee error: %v, use the fallback fee rate", err)
+	}
+
+	utxos, err := utxo.FromListUnspent(listUnspentResult)
+	if err != nil {
+		return nil, fmt.Errorf("%v,%v", env.ErrAmountInvalid.Error(), err)
+	}
+
+	params := utxo.NewP2PKHParams(utxo.FeeRateFromEstimate(feeResult, depinc.FallbackFeeRate))
+	selection, err := utxo.Select(utxo.Strategy(), utxos, amount, params)
+	if errors.Is(err, utxo.ErrInsufficientFunds) {
+		// TODO: think how to use same error
+		log.Errorf(
+			"insufficient balance: utxos: %v, transfer: %v, fee rate: %v",
+			len(utxos),
+			amount,
+			params.FeeRate,
+		)
+		return nil, env.ErrInsufficientBalance
+	}
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("select utxos for %v: %v", from, selection)
+
 	// new transaction
 	msgTx := wire.NewMsgTx(wire.TxVersion)
 
-	// TODO: optimizing the utxos selected
-	enoughUTXOAmount := float64(0)
 	// sign and check need this info
 	// btcutil.Amount is alias of int64
-	inputAccount := make([]btcutil.Amount, 0)
-	amountflag := false
-	for _, txIn := range listUnspentResult {
+	inputAccount := make([]btcutil.Amount, 0, len(selection.Inputs))
+	for _, txIn := range selection.Inputs {
 		txHash, err := chainhash.NewHashFromStr(txIn.TxID)
 		if err != nil {
 			return nil, err
 		}
-		// get in amount
-		inAmount, err := btcutil.NewAmount(txIn.Amount)
-		if err != nil {
-			return nil, fmt.Errorf("%v,%v", env.ErrAmountInvalid.Error(), err)
-		}
 
-		inputAccount = append(inputAccount, inAmount)
+		inputAccount = append(inputAccount, txIn.Amount)
 		msgTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(txHash, txIn.Vout), nil, nil))
-
-		// util sufficient funds are available
-		enoughUTXOAmount += txIn.Amount
-		if enoughUTXOAmount >= amount+depinc.DEPCGas {
-			amountflag = true
-			break
-		}
-	}
-
-	if !amountflag {
-		// TODO: think how to use same error
-		log.Errorf(
-			"insufficient balance: total: %v, transfer: %v, gas: %v",
-			enoughUTXOAmount,
-			amount,
-			depinc.DEPCGas,
-		)
-		return nil, env.ErrInsufficientBalance
 	}
 
 	fromScript, err := txscript.PayToAddrScript(fromAddr)
@@ -217,14 +226,9 @@ func preSign(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) ([]byte
 		return nil, fmt.Errorf("%v,%v", env.ErrAddressInvalid, err)
 	}
 
-	// cal change amount
-	changeAmount, err := btcutil.NewAmount(enoughUTXOAmount - amount - depinc.DEPCGas)
-	if err != nil {
-		return nil, fmt.Errorf("%v,%v", env.ErrAmountInvalid, err)
-	}
-
-	if changeAmount.ToBTC() > 0 {
-		msgTx.AddTxOut(wire.NewTxOut(int64(changeAmount), fromScript))
+	// change less than the dust limit is given to the fee
+	if selection.Change > 0 {
+		msgTx.AddTxOut(wire.NewTxOut(int64(selection.Change), fromScript))
 	}
 
 	toAddr, err := btcutil.DecodeAddress(to, depinc.DEPCNetMap[info.ENV])
@@ -237,12 +241,7 @@ func preSign(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) ([]byte
 		return nil, fmt.Errorf("%v,%v", env.ErrAddressInvalid, err)
 	}
 
-	outAmount, err := btcutil.NewAmount(amount)
-	if err != nil {
-		return nil, fmt.Errorf("%v,%v", env.ErrAmountInvalid, err)
-	}
-
-	msgTx.AddTxOut(wire.NewTxOut(int64(outAmount), toScript))
+	msgTx.AddTxOut(wire.NewTxOut(int64(amount), toScript))
 
 	_out := depinc.SignMsgTx{
 		BaseInfo:        info,
@@ -261,11 +260,15 @@ func broadcast(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out
 		return nil, err
 	}
 
+	// the hash is known before broadcast, the resent transaction is not sent twice
+	_hash := info.TxHash()
 	client := depinc.Client()
-	var _hash *chainhash.Hash
 	err = client.WithClient(ctx, func(cli *rpcclient.Client) (bool, error) {
-		_hash, err = cli.SendRawTransaction(info, false)
-		if err != nil || _hash == nil {
+		_, err = cli.SendRawTransaction(info, false)
+		if utxo.AlreadyBroadcastedErr(err) {
+			return false, nil
+		}
+		if err != nil {
 			return true, err
 		}
 		return false, err
@@ -3,9 +3,7 @@ package eth
 import (
 	"context"
 	"fmt"
-	"math"
 	"math/big"
-	"strings"
 	"time"
 
 	bc_client "github.com/NpoolPlatform/build-chain/pkg/client/v1"
@@ -30,6 +28,7 @@ const (
 	DialTimeout         = 3 * time.Second
 	EthExp              = -18
 	GasTolerance        = 1.25
+	TransferGasLimit    = uint64(21_000)
 	ChainType           = sphinxplugin.ChainType_Ethereum
 	ChainNativeUnit     = "ETH"
 	ChainAtomicUnit     = "Wei"
@@ -39,7 +38,13 @@ const (
 )
 
 var (
-	stopErrMsg = []string{GasTooLow, FundsTooLow, NonceTooLow, AmountInvalid, TokenTooLow}
+	errorMessages = []register.ErrorMessage{
+		{Message: GasTooLow, Kind: register.ErrorKindPermanent},
+		{Message: FundsTooLow, Kind: register.ErrorKindInsufficientFunds},
+		{Message: NonceTooLow, Kind: register.ErrorKindPermanent},
+		{Message: AmountInvalid, Kind: register.ErrorKindPermanent},
+		{Message: TokenTooLow, Kind: register.ErrorKindInsufficientFunds},
+	}
 
 	ethTokens = []coins.TokenInfo{
 		{Waight: 100, OfficialName: "Ethereum", Decimal: 18, Unit: "ETH", Name: ChainNativeCoinName, TokenType: coins.Ethereum, OfficialContract: ChainNativeCoinName, CoinType: sphinxplugin.CoinType_CoinTypeethereum},
@@ -114,24 +119,15 @@ func netHandle(tokenInfos []*coins.TokenInfo) error {
 	return nil
 }
 
-func TxFailErr(err error) bool {
-	if err == nil {
-		return false
-	}
+// ClassifyErr the kind of the node error
+func ClassifyErr(err error) register.ErrorKind {
+	return register.MessageClassifier(errorMessages...)(err)
+}
 
-	for _, v := range stopErrMsg {
-		if strings.Contains(err.Error(), v) {
-			return true
-		}
-	}
-	return false
+func TxFailErr(err error) bool {
+	return ClassifyErr(err).Abort()
 }
 
 func ToEth(value *big.Int) decimal.Decimal {
 	return decimal.NewFromBigInt(value, EthExp)
 }
-
-func ToWei(value float64) (*big.Int, bool) {
-	wei := big.NewFloat(0).Mul(big.NewFloat(value), big.NewFloat(math.Pow10(-EthExp)))
-	return big.NewInt(0).SetString(wei.Text('f', 0), 10)
-}
@@ -0,0 +1,71 @@
+package eth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// the node already has the transaction of the same hash, it is sent by the last broadcast
+var alreadyKnownErrMsg = []string{AlreadyKnown, `known transaction`, `already imported`}
+
+// AlreadyKnownErr the transaction is already in the pool of the node
+func AlreadyKnownErr(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	for _, v := range alreadyKnownErrMsg {
+		if strings.Contains(err.Error(), v) {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrNonceNotUsed the node report the nonce too low but the nonce is not used on chain,
+// the node may be behind the others
+var ErrNonceNotUsed = errors.New("the nonce is not used on chain")
+
+// SendTransaction send the signed transaction idempotently, the transaction which is
+// already in the pool or on chain is treated as sent, so are the same nonce transactions
+// replaced by it
+func SendTransaction(ctx context.Context, c *ethclient.Client, tx *types.Transaction, replacedTxIDs ...string) error {
+	err := c.SendTransaction(ctx, tx)
+	if err == nil || AlreadyKnownErr(err) {
+		return nil
+	}
+	if !strings.Contains(err.Error(), NonceTooLow) {
+		return err
+	}
+
+	// the nonce is used, it may be used by the transaction itself or one of the replaced ones,
+	// read the nonce before the transactions, so the one on chain before it is found
+	from, _err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if _err != nil {
+		return _err
+	}
+	nonce, _err := c.NonceAt(ctx, from, nil)
+	if _err != nil {
+		return _err
+	}
+
+	for _, txID := range append([]string{tx.Hash().Hex()}, replacedTxIDs...) {
+		_, _, _err := c.TransactionByHash(ctx, common.HexToHash(txID))
+		if errors.Is(_err, ethereum.NotFound) {
+			continue
+		}
+		return _err
+	}
+
+	if nonce <= tx.Nonce() {
+		return fmt.Errorf("%v, nonce %v of %v but chain nonce %v", ErrNonceNotUsed, tx.Nonce(), from.Hex(), nonce)
+	}
+	return err
+}
@@ -0,0 +1,90 @@
+package eth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/test-go/testify/assert"
+)
+
+func TestAlreadyKnownErr(t *testing.T) {
+	assert.False(t, AlreadyKnownErr(nil))
+	assert.True(t, AlreadyKnownErr(errors.New("already known")))
+	assert.True(t, AlreadyKnownErr(errors.New("known transaction: 0x1234")))
+	assert.True(t, AlreadyKnownErr(errors.New("transaction already imported")))
+	assert.False(t, AlreadyKnownErr(errors.New(NonceTooLow)))
+	assert.False(t, AlreadyKnownErr(errors.New(ReplaceUnderpriced)))
+}
+
+func TestSendTransaction(t *testing.T) {
+	ctx := context.Background()
+	tx, from := stubTx(t, 7, 1_200)
+	replaced := []*types.Transaction{}
+	replacedTxIDs := []string{}
+	for _, gasPrice := range []int64{1_000, 1_100} {
+		_tx, _ := stubTx(t, 7, gasPrice)
+		replaced = append(replaced, _tx)
+		replacedTxIDs = append(replacedTxIDs, _tx.Hash().Hex())
+	}
+
+	t.Run("sent", func(t *testing.T) {
+		node := newStubEth()
+		assert.Nil(t, SendTransaction(ctx, node.client(t), tx, replacedTxIDs...))
+		assert.Equal(t, 1, len(node.sentTxs()))
+	})
+
+	t.Run("already known", func(t *testing.T) {
+		node := newStubEth()
+		node.sendErr = errors.New(AlreadyKnown)
+		assert.Nil(t, SendTransaction(ctx, node.client(t), tx, replacedTxIDs...))
+	})
+
+	t.Run("on chain", func(t *testing.T) {
+		node := newStubEth()
+		node.sendErr = errors.New(NonceTooLow)
+		node.setNonce(from, 8, 8)
+		status := types.ReceiptStatusSuccessful
+		node.addTx(tx, &status)
+		assert.Nil(t, SendTransaction(ctx, node.client(t), tx, replacedTxIDs...))
+	})
+
+	t.Run("replaced on chain", func(t *testing.T) {
+		node := newStubEth()
+		node.sendErr = errors.New(NonceTooLow)
+		node.setNonce(from, 8, 8)
+		status := types.ReceiptStatusSuccessful
+		node.addTx(replaced[1], &status)
+		assert.Nil(t, SendTransaction(ctx, node.client(t), tx, replacedTxIDs...))
+	})
+
+	t.Run("nonce used", func(t *testing.T) {
+		node := newStubEth()
+		node.sendErr = errors.New(NonceTooLow)
+		node.setNonce(from, 8, 8)
+		err := SendTransaction(ctx, node.client(t), tx, replacedTxIDs...)
+		assert.NotNil(t, err)
+		assert.True(t, TxFailErr(err))
+	})
+
+	t.Run("nonce not used", func(t *testing.T) {
+		// the node which report the nonce too low is behind the others
+		node := newStubEth()
+		node.sendErr = errors.New(NonceTooLow)
+		node.setNonce(from, 7, 7)
+		err := SendTransaction(ctx, node.client(t), tx, replacedTxIDs...)
+		assert.NotNil(t, err)
+		assert.True(t, strings.Contains(err.Error(), ErrNonceNotUsed.Error()))
+		assert.False(t, TxFailErr(err))
+	})
+
+	t.Run("node error", func(t *testing.T) {
+		node := newStubEth()
+		node.sendErr = errors.New("rpc timeout")
+		err := SendTransaction(ctx, node.client(t), tx, replacedTxIDs...)
+		assert.NotNil(t, err)
+		assert.False(t, TxFailErr(err))
+	})
+}
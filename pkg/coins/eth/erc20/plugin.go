@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"math"
 	"math/big"
 
 	"github.com/NpoolPlatform/go-service-framework/pkg/logger"
@@ -15,13 +14,11 @@ import (
 	eth_plugin "github.com/NpoolPlatform/sphinx-plugin/pkg/coins/eth/eth"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/register"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/env"
-	"github.com/NpoolPlatform/sphinx-plugin/pkg/log"
 	plugin_types "github.com/NpoolPlatform/sphinx-plugin/pkg/types"
 
 	ethereum "github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 )
 
@@ -52,6 +49,11 @@ func init() {
 		register.OpSyncTx,
 		eth_plugin.SyncTxState,
 	)
+	register.RegisteTokenHandler(
+		coins.Erc20,
+		register.OpPreSignRelease,
+		eth.ReleasePreSign,
+	)
 }
 
 func walletBalance(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []byte, err error) {
@@ -93,21 +95,7 @@ func walletBalance(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (
 		return nil, fmt.Errorf("get erc20balance failed,%v", err)
 	}
 
-	balance, ok := big.NewFloat(0).SetString(bl.String())
-	if !ok {
-		return nil, errors.New("convert balance string to float64 error")
-	}
-
-	balance.Quo(balance, big.NewFloat(math.Pow10(tokenInfo.Decimal)))
-	f, exact := balance.Float64()
-	if exact != big.Exact {
-		log.Warnf("wallet balance transfer warning balance from->to %v-%v", balance.String(), f)
-	}
-
-	wbResp := &plugin_types.WalletBalanceResponse{
-		Balance:    f,
-		BalanceStr: balance.String(),
-	}
+	wbResp := plugin_types.NewWalletBalanceResponse(plugin_types.NewAmountFromAtomic(bl, tokenInfo.Decimal))
 	out, err = json.Marshal(wbResp)
 
 	return out, err
@@ -219,13 +207,11 @@ func PreSign(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []
 		return nil, env.ErrContractInvalid
 	}
 
-	amount := big.NewFloat(baseInfo.Value)
-	amount.Mul(amount, big.NewFloat(math.Pow10(tokenInfo.Decimal)))
-
-	amountBig, ok := big.NewInt(0).SetString(amount.Text('f', 0), 10)
-	if !ok {
-		return in, fmt.Errorf("%v,amount %v", eth.AmountInvalid, amount)
+	amount, err := baseInfo.GetAmount(tokenInfo.Decimal)
+	if err != nil {
+		return nil, err
 	}
+	amountBig := amount.BigInt()
 
 	_abi, err := Erc20tokenMetaData.GetAbi()
 	if err != nil {
@@ -248,7 +234,7 @@ func PreSign(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []
 		nonce       uint64
 		estimateGas uint64
 		ethBalance  *big.Int
-		gasPrice    *big.Int
+		fee         *eth.DynamicFee
 	)
 	callOpts := &bind.CallOpts{
 		Pending: true,
@@ -271,23 +257,18 @@ func PreSign(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []
 			return false, fmt.Errorf("%v,transfer amount %v", eth.TokenTooLow, amount)
 		}
 
-		chainID, err = cli.NetworkID(ctx)
+		chainID, err = cli.ChainID(ctx)
 		if err != nil || chainID == nil {
 			return true, err
 		}
 
-		nonce, err = cli.PendingNonceAt(ctx, common.HexToAddress(baseInfo.From))
-		if err != nil {
-			return true, err
-		}
-
 		ethBalance, err = cli.BalanceAt(ctx, common.HexToAddress(baseInfo.From), nil)
 		if err != nil || ethBalance == nil {
 			return true, err
 		}
 
-		gasPrice, err = cli.SuggestGasPrice(ctx)
-		if err != nil || gasPrice == nil {
+		fee, err = eth.SuggestDynamicFee(ctx, cli)
+		if err != nil {
 			return true, err
 		}
 
@@ -308,13 +289,12 @@ func PreSign(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []
 		return nil, err
 	}
 
-	if ethBalance == nil || gasPrice == nil {
+	if ethBalance == nil || fee == nil {
 		return nil, errors.New(eth.GetInfoFailed)
 	}
 
 	estimateGas = uint64(float64(estimateGas) * eth.GasTolerance)
-	estimateGasBig := big.NewInt(int64(estimateGas))
-	estimateFee := big.NewInt(0).Mul(gasPrice, estimateGasBig)
+	estimateFee := fee.MaxFee(estimateGas)
 
 	if ethBalance.Cmp(estimateFee) <= 0 {
 		logger.Sugar().Warnf("from %v, estimate fee >= balance: %v >= %v",
@@ -334,20 +314,26 @@ func PreSign(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []
 		return nil, errors.New("invalid eth amount")
 	}
 
+	nonce, err = eth.ReserveNonce(ctx, client, chainID, baseInfo.From)
+	if err != nil {
+		return nil, err
+	}
+
 	// build tx
-	tx := types.NewTransaction(
+	tx := fee.NewTx(
+		chainID,
 		nonce,
 		common.HexToAddress(tokenInfo.Contract),
 		big.NewInt(0),
 		estimateGas,
-		big.NewInt(gasPrice.Int64()),
 		input,
 	)
 
 	info := &eth.PreSignData{
-		ChainID: chainID,
-		From:    baseInfo.From,
-		Tx:      tx,
+		ChainID:  chainID,
+		From:     baseInfo.From,
+		Tx:       tx,
+		GasLimit: estimateGas,
 	}
 
 	out, err = json.Marshal(info)
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"time"
 
 	"github.com/NpoolPlatform/go-service-framework/pkg/logger"
 	"github.com/NpoolPlatform/message/npool/sphinxplugin"
@@ -51,13 +52,18 @@ func init() {
 		register.OpSyncTx,
 		SyncTxState,
 	)
+	register.RegisteTokenHandler(
+		coins.Ethereum,
+		register.OpPreSignRelease,
+		eth.ReleasePreSign,
+	)
 
-	err := register.RegisteAbortFuncErr(sphinxplugin.CoinType_CoinTypeethereum, eth.TxFailErr)
+	err := register.RegisteErrorClassifier(sphinxplugin.CoinType_CoinTypeethereum, eth.ClassifyErr)
 	if err != nil {
 		panic(err)
 	}
 
-	err = register.RegisteAbortFuncErr(sphinxplugin.CoinType_CoinTypetethereum, eth.TxFailErr)
+	err = register.RegisteErrorClassifier(sphinxplugin.CoinType_CoinTypetethereum, eth.ClassifyErr)
 	if err != nil {
 		panic(err)
 	}
@@ -91,12 +97,7 @@ func walletBalance(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (
 		return nil, err
 	}
 
-	balance := eth.ToEth(bl)
-
-	wbResp := &ct.WalletBalanceResponse{
-		Balance:    balance.InexactFloat64(),
-		BalanceStr: balance.String(),
-	}
+	wbResp := ct.NewWalletBalanceResponse(ct.NewAmountFromAtomic(bl, tokenInfo.Decimal))
 	out, err = json.Marshal(wbResp)
 
 	return out, err
@@ -179,10 +180,11 @@ func PreSign(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []
 		return nil, env.ErrAddressInvalid
 	}
 
-	amountBig, ok := eth.ToWei(baseInfo.Value)
-	if !ok {
-		return nil, errors.New(eth.AmountInvalid)
+	amount, err := baseInfo.GetAmount(tokenInfo.Decimal)
+	if err != nil {
+		return nil, err
 	}
+	amountBig := amount.BigInt()
 
 	client := eth.Client()
 
@@ -191,27 +193,28 @@ func PreSign(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []
 		nonce       uint64
 		estimateGas uint64
 		bl          *big.Int
-		gasPrice    *big.Int
-		gasLimit    = uint64(21_000)
+		fee         *eth.DynamicFee
+		toCode      []byte
+		gasLimit    = eth.TransferGasLimit
 	)
 
 	err = client.WithClient(ctx, func(ctx context.Context, cli *ethclient.Client) (bool, error) {
-		chainID, err = cli.NetworkID(ctx)
+		chainID, err = cli.ChainID(ctx)
 		if err != nil || chainID == nil {
 			return true, err
 		}
 
-		nonce, err = cli.PendingNonceAt(ctx, common.HexToAddress(baseInfo.From))
+		fee, err = eth.SuggestDynamicFee(ctx, cli)
 		if err != nil {
 			return true, err
 		}
 
-		gasPrice, err = cli.SuggestGasPrice(ctx)
-		if err != nil || gasPrice == nil {
+		to := common.HexToAddress(baseInfo.To)
+		toCode, err = cli.CodeAt(ctx, to, nil)
+		if err != nil {
 			return true, err
 		}
 
-		to := common.HexToAddress(baseInfo.To)
 		estimateGas, err = cli.EstimateGas(ctx, ethereum.CallMsg{
 			From:  common.HexToAddress(baseInfo.From),
 			To:    &to,
@@ -241,8 +244,14 @@ func PreSign(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []
 		return nil, errors.New(eth.AmountInvalid)
 	}
 
-	estimateGasBig := big.NewInt(int64(estimateGas))
-	estimateFee := big.NewInt(0).Mul(gasPrice, estimateGasBig)
+	// only the eoa recipient can use the transfer gas, the contract recipient
+	// (multisig wallet, deposit contract) may run code in its fallback
+	toContract := len(toCode) > 0
+	if toContract {
+		gasLimit = uint64(float64(estimateGas) * eth.GasTolerance)
+	}
+
+	estimateFee := fee.MaxFee(gasLimit)
 
 	totalFunds := big.NewInt(0).Add(estimateFee, amountBig)
 
@@ -264,20 +273,27 @@ func PreSign(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []
 		eth.ToEth(bl),
 	)
 
+	nonce, err = eth.ReserveNonce(ctx, client, chainID, baseInfo.From)
+	if err != nil {
+		return nil, err
+	}
+
 	// build tx
-	tx := types.NewTransaction(
+	tx := fee.NewTx(
+		chainID,
 		nonce,
 		common.HexToAddress(baseInfo.To),
 		amountBig,
 		gasLimit,
-		big.NewInt(gasPrice.Int64()),
 		nil,
 	)
 
 	info := &eth.PreSignData{
-		From:    baseInfo.From,
-		Tx:      tx,
-		ChainID: chainID,
+		From:       baseInfo.From,
+		Tx:         tx,
+		ChainID:    chainID,
+		ToContract: toContract,
+		GasLimit:   gasLimit,
 	}
 
 	out, err = json.Marshal(info)
@@ -301,9 +317,15 @@ func SendRawTransaction(ctx context.Context, in []byte, tokenInfo *coins.TokenIn
 	}
 
 	client := eth.Client()
+	var blockNum uint64
 	err = client.WithClient(ctx, func(ctx context.Context, c *ethclient.Client) (bool, error) {
-		err = c.SendTransaction(ctx, tx)
-		if err != nil && eth.TxFailErr(err) {
+		blockNum, err = c.BlockNumber(ctx)
+		if err != nil {
+			return true, err
+		}
+		// the resent transaction already in the pool or on chain is treated as sent
+		err = eth.SendTransaction(ctx, c, tx, signedData.ReplacedTxIDs...)
+		if err != nil && (eth.TxFailErr(err) || eth.ReplaceUnderpricedErr(err)) {
 			return false, err
 		}
 		if err != nil {
@@ -311,12 +333,25 @@ func SendRawTransaction(ctx context.Context, in []byte, tokenInfo *coins.TokenIn
 		}
 		return false, err
 	})
+	if err != nil && len(signedData.ReplacedTxIDs) > 0 && (eth.TxFailErr(err) || eth.ReplaceUnderpricedErr(err)) {
+		// the replaced transactions are pending, keep syncing them
+		logger.Sugar().Warnf("replace transaction %v rejected: %v", tx.Hash().Hex(), err)
+		return json.Marshal(eth.RejectedReplacement(signedData.ReplacedTxIDs, blockNum, time.Now()))
+	}
+	if err != nil && eth.TxFailErr(err) {
+		// the nonce will not be on chain, release it to fill the gap
+		eth.ReleaseNonce(tx)
+	}
 	if err != nil {
 		return nil, err
 	}
 
 	broadcastedData := ct.BroadcastInfo{
-		TxID: tx.Hash().Hex(),
+		TxID:          tx.Hash().Hex(),
+		SignedTx:      signedData.SignedTx,
+		ReplacedTxIDs: signedData.ReplacedTxIDs,
+		BlockNum:      blockNum,
+		BroadcastAt:   time.Now().Unix(),
 	}
 
 	return json.Marshal(broadcastedData)
@@ -329,11 +364,16 @@ func SyncTxState(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (ou
 	if err != nil {
 		return nil, err
 	}
+
 	client := eth.Client()
-	var isPending bool
+	var (
+		receipt     *types.Receipt
+		replacement *eth.Replacement
+	)
 	err = client.WithClient(ctx, func(ctx context.Context, c *ethclient.Client) (bool, error) {
-		_, isPending, err = c.TransactionByHash(ctx, common.HexToHash(broadcastedData.TxID))
-		if err != nil {
+		// the replacement with the bumped fee is built when the transaction is stuck
+		receipt, replacement, err = eth.SyncReplaceableTx(ctx, c, broadcastedData, eth.GetStuckPolicy(), time.Now())
+		if err != nil && !errors.Is(err, env.ErrWaitMessageOnChain) {
 			return true, err
 		}
 		return false, err
@@ -341,29 +381,24 @@ func SyncTxState(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (ou
 	if err != nil {
 		return nil, err
 	}
-	if isPending {
-		return nil, env.ErrWaitMessageOnChain
-	}
 
-	var receipt *types.Receipt
-	err = client.WithClient(ctx, func(ctx context.Context, c *ethclient.Client) (bool, error) {
-		receipt, err = c.TransactionReceipt(ctx, common.HexToHash(broadcastedData.TxID))
+	// the stuck transaction is replaced, route the replacement back to sign
+	if replacement != nil {
+		resign, err := json.Marshal(&eth.PreSignData{
+			From:          replacement.From,
+			ChainID:       replacement.ChainID,
+			Tx:            replacement.Tx,
+			GasLimit:      replacement.Tx.Gas(),
+			ReplacedTxIDs: replacement.ReplacedTxIDs,
+		})
 		if err != nil {
-			return true, err
+			return nil, err
 		}
-
-		return false, err
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	if receipt == nil {
-		return nil, env.ErrWaitMessageOnChain
+		return json.Marshal(&ct.SyncResponse{Resign: resign})
 	}
 
 	if receipt.Status == types.ReceiptStatusSuccessful {
-		sResp := &ct.SyncResponse{ExitCode: 0}
+		sResp := &ct.SyncResponse{ExitCode: 0, TxID: receipt.TxHash.Hex()}
 		out, err = json.Marshal(sResp)
 		if err != nil {
 			return nil, err
@@ -56,7 +56,7 @@ func Message(ctx context.Context, s3Store string, in []byte) ([]byte, error) {
 		return nil, err
 	}
 
-	signedTx, err := types.SignTx(preSignData.Tx, types.NewEIP155Signer(preSignData.ChainID), privateKey)
+	signedTx, err := types.SignTx(preSignData.Tx, types.NewLondonSigner(preSignData.ChainID), privateKey)
 	if err != nil {
 		return nil, err
 	}
@@ -68,7 +68,8 @@ func Message(ctx context.Context, s3Store string, in []byte) ([]byte, error) {
 	}
 
 	signedData := eth.SignedData{
-		SignedTx: signedTxBuf.Bytes(),
+		SignedTx:      signedTxBuf.Bytes(),
+		ReplacedTxIDs: preSignData.ReplacedTxIDs,
 	}
 
 	return json.Marshal(signedData)
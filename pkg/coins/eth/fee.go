@@ -0,0 +1,134 @@
+package eth
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/config"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	defaultBaseFeeMultiplier = 2
+	gweiExp                  = 9
+)
+
+// FeePolicy decide the max fee and tip of the eip-1559 transaction
+// MaxFeePerGas = BaseFee * BaseFeeMultiplier + GasTipCap
+type FeePolicy struct {
+	BaseFeeMultiplier float64
+	// nil means no limit
+	MaxTipCap *big.Int
+	MaxFeeCap *big.Int
+}
+
+// DynamicFee is the fee of the eip-1559 transaction, BaseFee is nil when the chain not support eip-1559
+type DynamicFee struct {
+	BaseFee   *big.Int
+	GasTipCap *big.Int
+	GasFeeCap *big.Int
+}
+
+func GetFeePolicy() *FeePolicy {
+	policy := &FeePolicy{BaseFeeMultiplier: defaultBaseFeeMultiplier}
+	envInfo := config.GetENV()
+	if envInfo == nil {
+		return policy
+	}
+	if envInfo.EthBaseFeeMultiplier > 0 {
+		policy.BaseFeeMultiplier = envInfo.EthBaseFeeMultiplier
+	}
+	if envInfo.EthMaxTipGwei > 0 {
+		policy.MaxTipCap = gweiToWei(envInfo.EthMaxTipGwei)
+	}
+	if envInfo.EthMaxFeeGwei > 0 {
+		policy.MaxFeeCap = gweiToWei(envInfo.EthMaxFeeGwei)
+	}
+	return policy
+}
+
+func gweiToWei(gwei float64) *big.Int {
+	return decimal.NewFromFloat(gwei).Shift(gweiExp).BigInt()
+}
+
+// Apply calculate the tip cap and fee cap by the base fee and the suggest tip
+func (p *FeePolicy) Apply(baseFee, suggestTip *big.Int) *DynamicFee {
+	tip := new(big.Int).Set(suggestTip)
+	if p.MaxTipCap != nil && tip.Cmp(p.MaxTipCap) > 0 {
+		tip.Set(p.MaxTipCap)
+	}
+
+	feeCap := decimal.NewFromBigInt(baseFee, 0).
+		Mul(decimal.NewFromFloat(p.BaseFeeMultiplier)).
+		Ceil().
+		BigInt()
+	feeCap.Add(feeCap, tip)
+	if p.MaxFeeCap != nil && feeCap.Cmp(p.MaxFeeCap) > 0 {
+		feeCap.Set(p.MaxFeeCap)
+	}
+	if tip.Cmp(feeCap) > 0 {
+		tip.Set(feeCap)
+	}
+
+	return &DynamicFee{
+		BaseFee:   new(big.Int).Set(baseFee),
+		GasTipCap: tip,
+		GasFeeCap: feeCap,
+	}
+}
+
+// SuggestDynamicFee query the latest base fee and the suggest tip, then apply the fee policy,
+// the chain which not support eip-1559 fallback to the legacy gas price
+func SuggestDynamicFee(ctx context.Context, cli *ethclient.Client) (*DynamicFee, error) {
+	header, err := cli.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if header.BaseFee == nil {
+		gasPrice, err := cli.SuggestGasPrice(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &DynamicFee{GasTipCap: gasPrice, GasFeeCap: gasPrice}, nil
+	}
+
+	tip, err := cli.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return GetFeePolicy().Apply(header.BaseFee, tip), nil
+}
+
+// MaxFee is the max fee the transaction may cost
+func (fee *DynamicFee) MaxFee(gasLimit uint64) *big.Int {
+	return new(big.Int).Mul(fee.GasFeeCap, new(big.Int).SetUint64(gasLimit))
+}
+
+// NewTx build DynamicFeeTx, or LegacyTx when the chain not support eip-1559
+func (fee *DynamicFee) NewTx(chainID *big.Int, nonce uint64, to common.Address, value *big.Int, gasLimit uint64, data []byte) *types.Transaction {
+	if fee.BaseFee == nil {
+		return types.NewTx(&types.LegacyTx{
+			Nonce:    nonce,
+			To:       &to,
+			Value:    value,
+			Gas:      gasLimit,
+			GasPrice: fee.GasFeeCap,
+			Data:     data,
+		})
+	}
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     nonce,
+		To:        &to,
+		Value:     value,
+		Gas:       gasLimit,
+		GasTipCap: fee.GasTipCap,
+		GasFeeCap: fee.GasFeeCap,
+		Data:      data,
+	})
+}
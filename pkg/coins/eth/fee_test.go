@@ -0,0 +1,90 @@
+package eth
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/config"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/test-go/testify/assert"
+)
+
+func TestFeePolicyApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  FeePolicy
+		baseFee int64
+		tip     int64
+		tipCap  int64
+		feeCap  int64
+	}{
+		{name: "default", policy: FeePolicy{BaseFeeMultiplier: 2}, baseFee: 100, tip: 3, tipCap: 3, feeCap: 203},
+		{name: "ceil", policy: FeePolicy{BaseFeeMultiplier: 1.25}, baseFee: 101, tip: 1, tipCap: 1, feeCap: 128},
+		{name: "max tip", policy: FeePolicy{BaseFeeMultiplier: 2, MaxTipCap: big.NewInt(5)}, baseFee: 100, tip: 50, tipCap: 5, feeCap: 205},
+		{name: "max fee", policy: FeePolicy{BaseFeeMultiplier: 2, MaxFeeCap: big.NewInt(150)}, baseFee: 100, tip: 3, tipCap: 3, feeCap: 150},
+		// the tip is never more than the fee cap
+		{name: "tip over fee", policy: FeePolicy{BaseFeeMultiplier: 2, MaxFeeCap: big.NewInt(20)}, baseFee: 100, tip: 30, tipCap: 20, feeCap: 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			suggestTip := big.NewInt(tt.tip)
+			fee := tt.policy.Apply(big.NewInt(tt.baseFee), suggestTip)
+			assert.Equal(t, tt.tipCap, fee.GasTipCap.Int64())
+			assert.Equal(t, tt.feeCap, fee.GasFeeCap.Int64())
+			assert.Equal(t, tt.baseFee, fee.BaseFee.Int64())
+			// the suggest tip is not changed
+			assert.Equal(t, tt.tip, suggestTip.Int64())
+		})
+	}
+}
+
+func TestGetFeePolicy(t *testing.T) {
+	config.SetENV(nil)
+	assert.Equal(t, &FeePolicy{BaseFeeMultiplier: defaultBaseFeeMultiplier}, GetFeePolicy())
+
+	config.SetENV(&config.ENVInfo{EthBaseFeeMultiplier: 1.5, EthMaxTipGwei: 2, EthMaxFeeGwei: 0.5})
+	defer config.SetENV(nil)
+	policy := GetFeePolicy()
+	assert.Equal(t, 1.5, policy.BaseFeeMultiplier)
+	assert.Equal(t, int64(2_000_000_000), policy.MaxTipCap.Int64())
+	assert.Equal(t, int64(500_000_000), policy.MaxFeeCap.Int64())
+}
+
+func TestDynamicFeeMaxFee(t *testing.T) {
+	fee := &DynamicFee{BaseFee: big.NewInt(100), GasTipCap: big.NewInt(2), GasFeeCap: big.NewInt(202)}
+	assert.Equal(t, int64(202*21_000), fee.MaxFee(TransferGasLimit).Int64())
+	assert.Equal(t, int64(0), fee.MaxFee(0).Int64())
+
+	tx := fee.NewTx(stubChainID, 1, common.Address{1}, big.NewInt(1), TransferGasLimit, nil)
+	assert.Equal(t, uint8(types.DynamicFeeTxType), tx.Type())
+	assert.Equal(t, int64(202), tx.GasFeeCap().Int64())
+	assert.Equal(t, int64(2), tx.GasTipCap().Int64())
+
+	legacy := &DynamicFee{GasTipCap: big.NewInt(10), GasFeeCap: big.NewInt(10)}
+	tx = legacy.NewTx(stubChainID, 1, common.Address{1}, big.NewInt(1), TransferGasLimit, nil)
+	assert.Equal(t, uint8(types.LegacyTxType), tx.Type())
+	assert.Equal(t, int64(10), tx.GasPrice().Int64())
+}
+
+func TestSuggestDynamicFee(t *testing.T) {
+	config.SetENV(nil)
+	node := newStubEth()
+	cli := node.client(t)
+
+	// the chain not support eip-1559 use the legacy gas price
+	fee, err := SuggestDynamicFee(context.Background(), cli)
+	assert.Nil(t, err)
+	assert.Nil(t, fee.BaseFee)
+	assert.Equal(t, int64(10), fee.GasFeeCap.Int64())
+	assert.Equal(t, int64(10), fee.GasTipCap.Int64())
+
+	node.header.BaseFee = big.NewInt(100)
+	fee, err = SuggestDynamicFee(context.Background(), cli)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(100), fee.BaseFee.Int64())
+	assert.Equal(t, int64(2), fee.GasTipCap.Int64())
+	assert.Equal(t, int64(202), fee.GasFeeCap.Int64())
+}
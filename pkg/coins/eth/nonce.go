@@ -0,0 +1,177 @@
+package eth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// the reserved nonce which not on chain after the timeout is treated as a gap,
+// the transaction may failed in sign or broadcast
+const nonceGapTimeout = 10 * time.Minute
+
+// NonceManager allocate the nonce of the same address locally, so the concurrent
+// transactions of the same address not get the same pending nonce from the chain
+type NonceManager struct {
+	mu       sync.Mutex
+	accounts map[string]*accountNonce
+}
+
+type accountNonce struct {
+	mu   sync.Mutex
+	next uint64
+	// reserved nonce and the reserve time
+	reserved map[uint64]time.Time
+	// released nonce which should be reused first, sorted
+	released []uint64
+}
+
+var nonceManager = &NonceManager{accounts: make(map[string]*accountNonce)}
+
+func Nonces() *NonceManager {
+	return nonceManager
+}
+
+func nonceKey(chainID *big.Int, from string) string {
+	return fmt.Sprintf("%v:%v", chainID, common.HexToAddress(from).Hex())
+}
+
+func (m *NonceManager) account(chainID *big.Int, from string) *accountNonce {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := nonceKey(chainID, from)
+	acc, ok := m.accounts[key]
+	if !ok {
+		acc = &accountNonce{reserved: make(map[uint64]time.Time)}
+		m.accounts[key] = acc
+	}
+	return acc
+}
+
+// Reserve allocate a nonce for the address, pendingNonce query the pending nonce from the chain
+// the released nonce is reused first, then the timeout reserved nonce which the chain still wait for,
+// otherwise a new nonce after the reserved ones
+func (m *NonceManager) Reserve(ctx context.Context, chainID *big.Int, from string, pendingNonce func(ctx context.Context) (uint64, error)) (uint64, error) {
+	acc := m.account(chainID, from)
+	acc.mu.Lock()
+	defer acc.mu.Unlock()
+
+	chainNonce, err := pendingNonce(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	nonce := acc.allocate(chainNonce, time.Now())
+	acc.reserved[nonce] = time.Now()
+	return nonce, nil
+}
+
+// Reconcile forget the nonce which is used on chain, so the reserved and released nonce
+// not grow when the address has no new transaction
+func (m *NonceManager) Reconcile(chainID *big.Int, from string, chainNonce uint64) {
+	acc := m.account(chainID, from)
+	acc.mu.Lock()
+	defer acc.mu.Unlock()
+
+	acc.reconcile(chainNonce)
+}
+
+func (acc *accountNonce) reconcile(chainNonce uint64) {
+	// the nonce less than the chain pending nonce is used
+	for nonce := range acc.reserved {
+		if nonce < chainNonce {
+			delete(acc.reserved, nonce)
+		}
+	}
+	idx := sort.Search(len(acc.released), func(i int) bool { return acc.released[i] >= chainNonce })
+	acc.released = acc.released[idx:]
+	if acc.next < chainNonce {
+		acc.next = chainNonce
+	}
+}
+
+func (acc *accountNonce) allocate(chainNonce uint64, now time.Time) uint64 {
+	acc.reconcile(chainNonce)
+
+	if len(acc.released) > 0 {
+		nonce := acc.released[0]
+		acc.released = acc.released[1:]
+		return nonce
+	}
+
+	if reserveAt, ok := acc.reserved[chainNonce]; ok && now.Sub(reserveAt) >= nonceGapTimeout {
+		return chainNonce
+	}
+
+	nonce := acc.next
+	acc.next++
+	return nonce
+}
+
+// Release give back the nonce which will not be on chain, eg: the broadcast is aborted
+func (m *NonceManager) Release(chainID *big.Int, from string, nonce uint64) {
+	acc := m.account(chainID, from)
+	acc.mu.Lock()
+	defer acc.mu.Unlock()
+
+	if _, ok := acc.reserved[nonce]; !ok {
+		return
+	}
+	delete(acc.reserved, nonce)
+
+	idx := sort.Search(len(acc.released), func(i int) bool { return acc.released[i] >= nonce })
+	if idx < len(acc.released) && acc.released[idx] == nonce {
+		return
+	}
+	acc.released = append(acc.released, 0)
+	copy(acc.released[idx+1:], acc.released[idx:])
+	acc.released[idx] = nonce
+}
+
+// ReserveNonce reserve the nonce of the address, client is the eth or bsc client
+func ReserveNonce(ctx context.Context, client EClientI, chainID *big.Int, from string) (uint64, error) {
+	return Nonces().Reserve(ctx, chainID, from, func(ctx context.Context) (pendingNonce uint64, err error) {
+		err = client.WithClient(ctx, func(ctx context.Context, cli *ethclient.Client) (bool, error) {
+			pendingNonce, err = cli.PendingNonceAt(ctx, common.HexToAddress(from))
+			if err != nil {
+				return true, err
+			}
+			return false, err
+		})
+		return pendingNonce, err
+	})
+}
+
+// ReleasePreSign release the nonce of the pre sign payload which is lost
+func ReleasePreSign(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) ([]byte, error) {
+	preSignData := &PreSignData{}
+	if err := json.Unmarshal(in, preSignData); err != nil {
+		return nil, err
+	}
+	if preSignData.Tx == nil {
+		return nil, nil
+	}
+
+	Nonces().Release(preSignData.ChainID, preSignData.From, preSignData.Tx.Nonce())
+	return nil, nil
+}
+
+// ReleaseNonce release the nonce of the transaction which will not be on chain
+func ReleaseNonce(tx *types.Transaction) {
+	chainID := tx.ChainId()
+	from, err := types.Sender(types.LatestSignerForChainID(chainID), tx)
+	if err != nil {
+		return
+	}
+	Nonces().Release(chainID, from.Hex(), tx.Nonce())
+}
@@ -0,0 +1,112 @@
+package eth
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/test-go/testify/assert"
+)
+
+const nonceTestFrom = "0x0000000000000000000000000000000000000001"
+
+func newNonceManager() *NonceManager {
+	return &NonceManager{accounts: make(map[string]*accountNonce)}
+}
+
+func reserveNonce(t *testing.T, m *NonceManager, chainNonce uint64) uint64 {
+	nonce, err := m.Reserve(context.Background(), stubChainID, nonceTestFrom, func(ctx context.Context) (uint64, error) {
+		return chainNonce, nil
+	})
+	assert.Nil(t, err)
+	return nonce
+}
+
+func TestNonceAllocate(t *testing.T) {
+	now := time.Now()
+	acc := &accountNonce{reserved: make(map[uint64]time.Time)}
+
+	// the concurrent transactions get the successive nonce from the chain pending nonce
+	for want := uint64(5); want < 8; want++ {
+		nonce := acc.allocate(5, now)
+		assert.Equal(t, want, nonce)
+		acc.reserved[nonce] = now
+	}
+
+	// the chain wait for the nonce which is reserved but not broadcast, fill the gap
+	assert.Equal(t, uint64(8), acc.allocate(5, now.Add(nonceGapTimeout-time.Second)))
+	acc.reserved[8] = now
+	assert.Equal(t, uint64(5), acc.allocate(5, now.Add(nonceGapTimeout)))
+
+	// the nonce used on chain is forgot
+	acc.released = []uint64{6}
+	assert.Equal(t, uint64(9), acc.allocate(7, now))
+	assert.Equal(t, 0, len(acc.released))
+	_, ok := acc.reserved[6]
+	assert.False(t, ok)
+
+	// the chain pending nonce is after the local one
+	assert.Equal(t, uint64(20), acc.allocate(20, now))
+	assert.Equal(t, 0, len(acc.reserved))
+}
+
+func TestNonceRelease(t *testing.T) {
+	m := newNonceManager()
+	for want := uint64(5); want < 9; want++ {
+		assert.Equal(t, want, reserveNonce(t, m, 5))
+	}
+
+	// the released nonce is reused first, the lowest is the first
+	m.Release(stubChainID, nonceTestFrom, 7)
+	m.Release(stubChainID, nonceTestFrom, 6)
+	m.Release(stubChainID, nonceTestFrom, 6)
+	// the nonce not reserved is not released
+	m.Release(stubChainID, nonceTestFrom, 100)
+
+	assert.Equal(t, uint64(6), reserveNonce(t, m, 5))
+	assert.Equal(t, uint64(7), reserveNonce(t, m, 5))
+	assert.Equal(t, uint64(9), reserveNonce(t, m, 5))
+
+	// the other address is not affected
+	nonce, err := m.Reserve(context.Background(), stubChainID, "0x0000000000000000000000000000000000000002", func(ctx context.Context) (uint64, error) {
+		return 0, nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), nonce)
+}
+
+func TestNonceReconcile(t *testing.T) {
+	m := newNonceManager()
+	for i := 0; i < 3; i++ {
+		reserveNonce(t, m, 5)
+	}
+	m.Release(stubChainID, nonceTestFrom, 6)
+
+	m.Reconcile(stubChainID, nonceTestFrom, 7)
+	acc := m.account(stubChainID, nonceTestFrom)
+	assert.Equal(t, 0, len(acc.released))
+	assert.Equal(t, 1, len(acc.reserved))
+	_, ok := acc.reserved[7]
+	assert.True(t, ok)
+	assert.Equal(t, uint64(8), reserveNonce(t, m, 7))
+}
+
+func TestReleasePreSign(t *testing.T) {
+	nonce := reserveNonce(t, Nonces(), 3)
+	tx, from := stubTx(t, nonce, 10)
+
+	preSign, err := json.Marshal(&PreSignData{From: from.Hex(), ChainID: stubChainID, Tx: tx})
+	assert.Nil(t, err)
+	// the nonce is reserved by the other address
+	_, err = ReleasePreSign(context.Background(), preSign, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, nonce+1, reserveNonce(t, Nonces(), 3))
+
+	preSign, err = json.Marshal(&PreSignData{From: nonceTestFrom, ChainID: big.NewInt(1337), Tx: tx})
+	assert.Nil(t, err)
+	_, err = ReleasePreSign(context.Background(), preSign, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, nonce, reserveNonce(t, Nonces(), 3))
+}
@@ -0,0 +1,331 @@
+package eth
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/NpoolPlatform/go-service-framework/pkg/logger"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/config"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/env"
+	ct "github.com/NpoolPlatform/sphinx-plugin/pkg/types"
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+const (
+	defaultStuckTimeout    = 600 * time.Second
+	defaultFeeBumpPercent  = 20
+	defaultMaxReplaceTimes = 3
+	// the node reject the replacement transaction which bump fee less than 10%
+	minFeeBumpPercent = 10
+
+	ReplaceUnderpriced = `replacement transaction underpriced`
+	AlreadyKnown       = `already known`
+)
+
+// ErrNonceUsed none of the tracked transactions is found but the nonce is used on chain,
+// the transaction is not failed until it is checked by hand, the node may not index it
+var ErrNonceUsed = errors.New("nonce used by the untracked transaction")
+
+// when the replacement transaction broadcast failed by these errors,
+// one of the tracked transactions is still pending or on chain
+var replaceIgnoreErrMsg = []string{NonceTooLow, ReplaceUnderpriced, AlreadyKnown}
+
+// StuckPolicy decide when the pending transaction should be replaced by the same nonce
+// transaction with higher fee
+type StuckPolicy struct {
+	// zero means disable
+	Blocks  uint64
+	Timeout time.Duration
+
+	FeeBumpPercent  int64
+	MaxReplaceTimes int
+}
+
+func GetStuckPolicy() *StuckPolicy {
+	policy := &StuckPolicy{
+		Timeout:         defaultStuckTimeout,
+		FeeBumpPercent:  defaultFeeBumpPercent,
+		MaxReplaceTimes: defaultMaxReplaceTimes,
+	}
+	envInfo := config.GetENV()
+	if envInfo == nil {
+		return policy
+	}
+
+	policy.Blocks = envInfo.EvmStuckBlocks
+	if envInfo.EvmStuckTimeout >= 0 {
+		policy.Timeout = time.Duration(envInfo.EvmStuckTimeout) * time.Second
+	}
+	if envInfo.EvmFeeBumpPercent > 0 {
+		policy.FeeBumpPercent = envInfo.EvmFeeBumpPercent
+	}
+	if policy.FeeBumpPercent < minFeeBumpPercent {
+		policy.FeeBumpPercent = minFeeBumpPercent
+	}
+	if envInfo.EvmMaxReplaceTimes >= 0 {
+		policy.MaxReplaceTimes = envInfo.EvmMaxReplaceTimes
+	}
+	return policy
+}
+
+// IsStuck the transaction is pending for the stuck blocks or the stuck timeout since the last
+// broadcast, the broadcast info without block num and broadcast time is built by old version
+// and never stuck
+func (p *StuckPolicy) IsStuck(info *ct.BroadcastInfo, blockNum uint64, now time.Time) bool {
+	if len(info.ReplacedTxIDs) >= p.MaxReplaceTimes {
+		return false
+	}
+	if p.Blocks > 0 && info.BlockNum > 0 && blockNum >= info.BlockNum+p.Blocks {
+		return true
+	}
+	if p.Timeout > 0 && info.BroadcastAt > 0 && now.Sub(time.Unix(info.BroadcastAt, 0)) >= p.Timeout {
+		return true
+	}
+	return false
+}
+
+// bump round up, so the small fee is bumped too
+func (p *StuckPolicy) bump(value *big.Int) *big.Int {
+	bumped := new(big.Int).Mul(value, big.NewInt(100+p.FeeBumpPercent))
+	bumped.Add(bumped, big.NewInt(99))
+	return bumped.Div(bumped, big.NewInt(100))
+}
+
+// ReplaceTx build the same nonce transaction of the stuck tx, the fee is bumped from the
+// stuck one and never less than the current fee market
+func (p *StuckPolicy) ReplaceTx(ctx context.Context, cli *ethclient.Client, tx *types.Transaction) (*types.Transaction, error) {
+	if tx.Type() == types.DynamicFeeTxType {
+		fee, err := SuggestDynamicFee(ctx, cli)
+		if err != nil {
+			return nil, err
+		}
+
+		tip := maxBig(p.bump(tx.GasTipCap()), fee.GasTipCap)
+		feeCap := maxBig(p.bump(tx.GasFeeCap()), fee.GasFeeCap)
+		return types.NewTx(&types.DynamicFeeTx{
+			ChainID:    tx.ChainId(),
+			Nonce:      tx.Nonce(),
+			To:         tx.To(),
+			Value:      tx.Value(),
+			Gas:        tx.Gas(),
+			GasTipCap:  tip,
+			GasFeeCap:  maxBig(tip, feeCap),
+			Data:       tx.Data(),
+			AccessList: tx.AccessList(),
+		}), nil
+	}
+
+	gasPrice, err := cli.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return types.NewTx(&types.LegacyTx{
+		Nonce:    tx.Nonce(),
+		To:       tx.To(),
+		Value:    tx.Value(),
+		Gas:      tx.Gas(),
+		GasPrice: maxBig(p.bump(tx.GasPrice()), gasPrice),
+		Data:     tx.Data(),
+	}), nil
+}
+
+// Replacement the same nonce transaction of the stuck one with the bumped fee, it is routed
+// back to sign, the replaced transactions are still tracked after it is broadcast
+type Replacement struct {
+	From          string
+	ChainID       *big.Int
+	Tx            *types.Transaction
+	ReplacedTxIDs []string
+}
+
+// signedTx decode the signed transaction, the info built by old version has none
+func signedTx(info *ct.BroadcastInfo) (*types.Transaction, error) {
+	if len(info.SignedTx) == 0 {
+		return nil, nil
+	}
+
+	tx := new(types.Transaction)
+	if err := rlp.Decode(bytes.NewReader(info.SignedTx), tx); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// TrackedTxIDs return the replaced tx ids and the tx id, the newest is the last
+func TrackedTxIDs(info *ct.BroadcastInfo) []string {
+	txIDs := make([]string, 0, len(info.ReplacedTxIDs)+1)
+	txIDs = append(txIDs, info.ReplacedTxIDs...)
+	return append(txIDs, info.TxID)
+}
+
+// SyncReplaceableTx check the transaction and the replaced ones of the same nonce, the receipt
+// is returned when one of them is on chain. The replacement with the bumped fee is returned when
+// the transaction is stuck, the signed transaction is broadcast again when all of them are dropped
+// by the pool, env.ErrWaitMessageOnChain is returned until one of them is on chain
+func SyncReplaceableTx(
+	ctx context.Context,
+	cli *ethclient.Client,
+	info *ct.BroadcastInfo,
+	policy *StuckPolicy,
+	now time.Time,
+) (*types.Receipt, *Replacement, error) {
+	tx, err := signedTx(info)
+	if err != nil {
+		return nil, nil, err
+	}
+	txIDs := TrackedTxIDs(info)
+
+	// read the nonce before the transactions, so the one on chain before it is found
+	nonceUsed := false
+	if tx != nil {
+		from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+		if err != nil {
+			return nil, nil, err
+		}
+		nonce, err := cli.NonceAt(ctx, from, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		nonceUsed = nonce > tx.Nonce()
+
+		// forget the used nonce, the address may have no new pre sign to reconcile it
+		pendingNonce, err := cli.PendingNonceAt(ctx, from)
+		if err != nil {
+			return nil, nil, err
+		}
+		Nonces().Reconcile(tx.ChainId(), from.Hex(), pendingNonce)
+	}
+
+	var pendingTx *types.Transaction
+	for i := len(txIDs) - 1; i >= 0; i-- {
+		txHash := common.HexToHash(txIDs[i])
+		_tx, isPending, err := cli.TransactionByHash(ctx, txHash)
+		if errors.Is(err, ethereum.NotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if isPending {
+			if pendingTx == nil {
+				pendingTx = _tx
+			}
+			continue
+		}
+		receipt, err := cli.TransactionReceipt(ctx, txHash)
+		return receipt, nil, err
+	}
+
+	switch {
+	case pendingTx == nil && tx == nil:
+		return nil, nil, ethereum.NotFound
+	case pendingTx == nil && nonceUsed:
+		return nil, nil, ErrNonceUsed
+	case pendingTx == nil:
+		// all of them are dropped by the pool, the failed broadcast must not fail the
+		// transaction, the tracked ones may still be on chain
+		logger.Sugar().Warnf("transaction %v not found, broadcast it again", info.TxID)
+		if err := SendTransaction(ctx, cli, tx, info.ReplacedTxIDs...); err != nil && !ReplaceIgnoreErr(err) {
+			logger.Sugar().Warnf("broadcast %v error: %v", info.TxID, err)
+		}
+		return nil, nil, env.ErrWaitMessageOnChain
+	}
+
+	blockNum, err := cli.BlockNumber(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !policy.IsStuck(info, blockNum, now) {
+		return nil, nil, env.ErrWaitMessageOnChain
+	}
+
+	// the signed one is the newest, the pending one of the node is used when it is unknown
+	if tx == nil {
+		tx = pendingTx
+	}
+	chainID := tx.ChainId()
+	from, err := types.Sender(types.LatestSignerForChainID(chainID), tx)
+	if err != nil {
+		return nil, nil, err
+	}
+	replaceTx, err := policy.ReplaceTx(ctx, cli, tx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the replacement is not built when the balance can not cover the bumped fee, the
+	// pending one may still be on chain
+	balance, err := cli.BalanceAt(ctx, from, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if balance.Cmp(replaceTx.Cost()) < 0 {
+		logger.Sugar().Warnf("transaction %v stuck, balance %v of %v can not cover the replacement cost %v",
+			info.TxID,
+			balance,
+			from.Hex(),
+			replaceTx.Cost(),
+		)
+		return nil, nil, env.ErrWaitMessageOnChain
+	}
+
+	logger.Sugar().Warnf("transaction %v stuck, replace it with nonce %v replaces %v",
+		info.TxID,
+		replaceTx.Nonce(),
+		len(txIDs),
+	)
+	return nil, &Replacement{
+		From:          from.Hex(),
+		ChainID:       chainID,
+		Tx:            replaceTx,
+		ReplacedTxIDs: txIDs,
+	}, nil
+}
+
+// RejectedReplacement the broadcast info of the replacement which is rejected by the node,
+// the replaced transactions are still synced, one of them may be on chain
+func RejectedReplacement(replacedTxIDs []string, blockNum uint64, now time.Time) *ct.BroadcastInfo {
+	last := len(replacedTxIDs) - 1
+	return &ct.BroadcastInfo{
+		TxID:          replacedTxIDs[last],
+		ReplacedTxIDs: replacedTxIDs[:last],
+		BlockNum:      blockNum,
+		BroadcastAt:   now.Unix(),
+	}
+}
+
+// ReplaceUnderpricedErr the replacement is rejected by the node, the fee of the pending one is higher
+func ReplaceUnderpricedErr(err error) bool {
+	return err != nil && strings.Contains(err.Error(), ReplaceUnderpriced)
+}
+
+// ReplaceIgnoreErr the replacement transaction is not needed any more
+func ReplaceIgnoreErr(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	for _, v := range replaceIgnoreErrMsg {
+		if strings.Contains(err.Error(), v) {
+			return true
+		}
+	}
+	return false
+}
+
+func maxBig(a, b *big.Int) *big.Int {
+	if a.Cmp(b) >= 0 {
+		return new(big.Int).Set(a)
+	}
+	return new(big.Int).Set(b)
+}
@@ -0,0 +1,347 @@
+package eth
+
+import (
+	"context"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/NpoolPlatform/go-service-framework/pkg/logger"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/config"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/env"
+	ct "github.com/NpoolPlatform/sphinx-plugin/pkg/types"
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/test-go/testify/assert"
+)
+
+func TestStuckPolicyIsStuck(t *testing.T) {
+	now := time.Unix(10_000, 0)
+
+	tests := []struct {
+		name     string
+		policy   StuckPolicy
+		info     ct.BroadcastInfo
+		blockNum uint64
+		stuck    bool
+	}{
+		{
+			name:   "pending",
+			policy: StuckPolicy{Timeout: time.Minute, MaxReplaceTimes: 3},
+			info:   ct.BroadcastInfo{BroadcastAt: now.Unix() - 59},
+			stuck:  false,
+		},
+		{
+			name:   "timeout",
+			policy: StuckPolicy{Timeout: time.Minute, MaxReplaceTimes: 3},
+			info:   ct.BroadcastInfo{BroadcastAt: now.Unix() - 60},
+			stuck:  true,
+		},
+		{
+			name:   "replaced",
+			policy: StuckPolicy{Timeout: time.Minute, MaxReplaceTimes: 3},
+			info:   ct.BroadcastInfo{BroadcastAt: now.Unix() - 60, ReplacedTxIDs: []string{"0x1", "0x2"}},
+			stuck:  true,
+		},
+		{
+			name:   "max replace times",
+			policy: StuckPolicy{Timeout: time.Minute, MaxReplaceTimes: 2},
+			info:   ct.BroadcastInfo{BroadcastAt: now.Unix() - 600, ReplacedTxIDs: []string{"0x1", "0x2"}},
+			stuck:  false,
+		},
+		{
+			name:   "disable replace",
+			policy: StuckPolicy{Timeout: time.Minute},
+			info:   ct.BroadcastInfo{BroadcastAt: now.Unix() - 600},
+			stuck:  false,
+		},
+		{
+			name:     "blocks",
+			policy:   StuckPolicy{Blocks: 10, MaxReplaceTimes: 3},
+			info:     ct.BroadcastInfo{BlockNum: 100},
+			blockNum: 110,
+			stuck:    true,
+		},
+		{
+			name:     "blocks pending",
+			policy:   StuckPolicy{Blocks: 10, MaxReplaceTimes: 3},
+			info:     ct.BroadcastInfo{BlockNum: 100},
+			blockNum: 109,
+			stuck:    false,
+		},
+		{
+			name:     "blocks before timeout",
+			policy:   StuckPolicy{Blocks: 10, Timeout: time.Minute, MaxReplaceTimes: 3},
+			info:     ct.BroadcastInfo{BlockNum: 100, BroadcastAt: now.Unix()},
+			blockNum: 110,
+			stuck:    true,
+		},
+		{
+			name:     "old version",
+			policy:   StuckPolicy{Blocks: 10, Timeout: time.Minute, MaxReplaceTimes: 3},
+			info:     ct.BroadcastInfo{},
+			blockNum: 1_000,
+			stuck:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.stuck, tt.policy.IsStuck(&tt.info, tt.blockNum, now))
+		})
+	}
+}
+
+func TestGetStuckPolicy(t *testing.T) {
+	config.SetENV(nil)
+	assert.Equal(t, &StuckPolicy{
+		Timeout:         defaultStuckTimeout,
+		FeeBumpPercent:  defaultFeeBumpPercent,
+		MaxReplaceTimes: defaultMaxReplaceTimes,
+	}, GetStuckPolicy())
+
+	config.SetENV(&config.ENVInfo{EvmStuckBlocks: 5, EvmFeeBumpPercent: 5, EvmMaxReplaceTimes: 0})
+	defer config.SetENV(nil)
+	policy := GetStuckPolicy()
+	assert.Equal(t, uint64(5), policy.Blocks)
+	assert.Equal(t, time.Duration(0), policy.Timeout)
+	// the node reject the replacement which bump less than 10%
+	assert.Equal(t, int64(minFeeBumpPercent), policy.FeeBumpPercent)
+	assert.Equal(t, 0, policy.MaxReplaceTimes)
+}
+
+func TestStuckPolicyReplaceTx(t *testing.T) {
+	ctx := context.Background()
+	policy := &StuckPolicy{FeeBumpPercent: 20, MaxReplaceTimes: 3}
+
+	// round up, the small fee is bumped too
+	assert.Equal(t, int64(1_200), policy.bump(big.NewInt(1_000)).Int64())
+	assert.Equal(t, int64(132), policy.bump(big.NewInt(110)).Int64())
+	assert.Equal(t, int64(2), policy.bump(big.NewInt(1)).Int64())
+
+	legacy, _ := stubTx(t, 7, 1_000)
+	t.Run("legacy", func(t *testing.T) {
+		node := newStubEth()
+		tx, err := policy.ReplaceTx(ctx, node.client(t), legacy)
+		assert.Nil(t, err)
+		assert.Equal(t, uint8(types.LegacyTxType), tx.Type())
+		assert.Equal(t, int64(1_200), tx.GasPrice().Int64())
+		assert.Equal(t, legacy.Nonce(), tx.Nonce())
+		assert.Equal(t, legacy.To(), tx.To())
+		assert.Equal(t, legacy.Value(), tx.Value())
+		assert.Equal(t, legacy.Gas(), tx.Gas())
+		assert.Equal(t, legacy.Data(), tx.Data())
+	})
+
+	t.Run("legacy fee market", func(t *testing.T) {
+		// the bumped fee is less than the current gas price
+		node := newStubEth()
+		node.gasPrice = big.NewInt(5_000)
+		tx, err := policy.ReplaceTx(ctx, node.client(t), legacy)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(5_000), tx.GasPrice().Int64())
+	})
+
+	dynamic := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   stubChainID,
+		Nonce:     7,
+		To:        &common.Address{1},
+		Value:     big.NewInt(1),
+		Gas:       TransferGasLimit,
+		GasTipCap: big.NewInt(100),
+		GasFeeCap: big.NewInt(110),
+		Data:      []byte{1, 2},
+	})
+	t.Run("dynamic", func(t *testing.T) {
+		// the current fee cap is 50 * 2 + 2
+		node := newStubEth()
+		node.header.BaseFee = big.NewInt(50)
+		tx, err := policy.ReplaceTx(ctx, node.client(t), dynamic)
+		assert.Nil(t, err)
+		assert.Equal(t, uint8(types.DynamicFeeTxType), tx.Type())
+		assert.Equal(t, int64(120), tx.GasTipCap().Int64())
+		assert.Equal(t, int64(132), tx.GasFeeCap().Int64())
+		assert.Equal(t, dynamic.ChainId(), tx.ChainId())
+		assert.Equal(t, dynamic.Nonce(), tx.Nonce())
+		assert.Equal(t, dynamic.Data(), tx.Data())
+	})
+
+	t.Run("dynamic fee market", func(t *testing.T) {
+		// the current fee cap is 1000 * 2 + 200
+		node := newStubEth()
+		node.header.BaseFee = big.NewInt(1_000)
+		node.tipCap = big.NewInt(200)
+		tx, err := policy.ReplaceTx(ctx, node.client(t), dynamic)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(200), tx.GasTipCap().Int64())
+		assert.Equal(t, int64(2_200), tx.GasFeeCap().Int64())
+	})
+
+	t.Run("fee cap less than tip", func(t *testing.T) {
+		node := newStubEth()
+		node.header.BaseFee = big.NewInt(1)
+		tx, err := policy.ReplaceTx(ctx, node.client(t), types.NewTx(&types.DynamicFeeTx{
+			ChainID:   stubChainID,
+			GasTipCap: big.NewInt(100),
+			GasFeeCap: big.NewInt(50),
+		}))
+		assert.Nil(t, err)
+		assert.Equal(t, tx.GasTipCap(), tx.GasFeeCap())
+	})
+}
+
+func TestSyncReplaceableTx(t *testing.T) {
+	assert.Nil(t, logger.Init(logger.DebugLevel, filepath.Join(t.TempDir(), "sphinx-plugin.log")))
+
+	policy := &StuckPolicy{Timeout: time.Minute, FeeBumpPercent: 20, MaxReplaceTimes: 2}
+	tx, from := stubTx(t, 7, 1_000)
+	info := &ct.BroadcastInfo{
+		TxID:        tx.Hash().Hex(),
+		SignedTx:    stubRLP(t, tx),
+		BroadcastAt: time.Now().Unix(),
+	}
+
+	now := time.Unix(info.BroadcastAt, 0)
+	stuck := now.Add(policy.Timeout)
+	ctx := context.Background()
+
+	t.Run("pending", func(t *testing.T) {
+		node := newStubEth()
+		node.setNonce(from, 7, 8)
+		node.addTx(tx, nil)
+
+		receipt, replacement, err := SyncReplaceableTx(ctx, node.client(t), info, policy, now)
+		assert.Nil(t, receipt)
+		assert.Nil(t, replacement)
+		assert.Equal(t, env.ErrWaitMessageOnChain.Error(), err.Error())
+		assert.Equal(t, 0, len(node.sentTxs()))
+	})
+
+	t.Run("stuck", func(t *testing.T) {
+		node := newStubEth()
+		node.setNonce(from, 7, 8)
+		node.setBalance(from, big.NewInt(1_000_000_000))
+		node.addTx(tx, nil)
+
+		receipt, replacement, err := SyncReplaceableTx(ctx, node.client(t), info, policy, stuck)
+		assert.Nil(t, err)
+		assert.Nil(t, receipt)
+		assert.Equal(t, from.Hex(), replacement.From)
+		assert.Equal(t, stubChainID, replacement.ChainID)
+		assert.Equal(t, []string{tx.Hash().Hex()}, replacement.ReplacedTxIDs)
+		assert.Equal(t, tx.Nonce(), replacement.Tx.Nonce())
+		assert.Equal(t, int64(1_200), replacement.Tx.GasPrice().Int64())
+		// the replacement is routed back to sign, nothing is broadcast by sync
+		assert.Equal(t, 0, len(node.sentTxs()))
+	})
+
+	t.Run("stuck without funds", func(t *testing.T) {
+		// the balance can not cover the bumped fee, keep waiting the pending one
+		node := newStubEth()
+		node.setNonce(from, 7, 8)
+		node.setBalance(from, tx.Cost())
+		node.addTx(tx, nil)
+
+		_, replacement, err := SyncReplaceableTx(ctx, node.client(t), info, policy, stuck)
+		assert.Nil(t, replacement)
+		assert.Equal(t, env.ErrWaitMessageOnChain.Error(), err.Error())
+	})
+
+	replacementTx, _ := stubTx(t, 7, 1_200)
+	replacedInfo := &ct.BroadcastInfo{
+		TxID:          replacementTx.Hash().Hex(),
+		SignedTx:      stubRLP(t, replacementTx),
+		ReplacedTxIDs: []string{tx.Hash().Hex()},
+		BroadcastAt:   info.BroadcastAt,
+	}
+
+	t.Run("replaced on chain", func(t *testing.T) {
+		node := newStubEth()
+		node.setNonce(from, 8, 8)
+		status := types.ReceiptStatusSuccessful
+		node.addTx(tx, &status)
+
+		receipt, replacement, err := SyncReplaceableTx(ctx, node.client(t), replacedInfo, policy, stuck)
+		assert.Nil(t, err)
+		assert.Nil(t, replacement)
+		assert.Equal(t, tx.Hash(), receipt.TxHash)
+		assert.Equal(t, 0, len(node.sentTxs()))
+	})
+
+	t.Run("replace again", func(t *testing.T) {
+		node := newStubEth()
+		node.setNonce(from, 7, 8)
+		node.setBalance(from, big.NewInt(1_000_000_000))
+		node.addTx(tx, nil)
+		node.addTx(replacementTx, nil)
+
+		_, replacement, err := SyncReplaceableTx(ctx, node.client(t), replacedInfo, policy, stuck)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{tx.Hash().Hex(), replacementTx.Hash().Hex()}, replacement.ReplacedTxIDs)
+		assert.Equal(t, int64(1_440), replacement.Tx.GasPrice().Int64())
+	})
+
+	t.Run("max replaces", func(t *testing.T) {
+		node := newStubEth()
+		node.setNonce(from, 7, 8)
+		node.setBalance(from, big.NewInt(1_000_000_000))
+		node.addTx(replacementTx, nil)
+
+		maxInfo := *replacedInfo
+		maxInfo.ReplacedTxIDs = []string{common.Hash{1}.Hex(), tx.Hash().Hex()}
+		_, replacement, err := SyncReplaceableTx(ctx, node.client(t), &maxInfo, policy, now.Add(10*policy.Timeout))
+		assert.Nil(t, replacement)
+		assert.Equal(t, env.ErrWaitMessageOnChain.Error(), err.Error())
+	})
+
+	t.Run("rejected replacement", func(t *testing.T) {
+		// the signed transaction is unknown, the pending one of the node is replaced
+		node := newStubEth()
+		node.setNonce(from, 7, 8)
+		node.setBalance(from, big.NewInt(1_000_000_000))
+		node.addTx(tx, nil)
+
+		rejected := RejectedReplacement([]string{tx.Hash().Hex()}, 100, now)
+		assert.Equal(t, &ct.BroadcastInfo{TxID: tx.Hash().Hex(), ReplacedTxIDs: []string{}, BlockNum: 100, BroadcastAt: now.Unix()}, rejected)
+
+		_, replacement, err := SyncReplaceableTx(ctx, node.client(t), rejected, policy, now)
+		assert.Nil(t, replacement)
+		assert.Equal(t, env.ErrWaitMessageOnChain.Error(), err.Error())
+
+		_, replacement, err = SyncReplaceableTx(ctx, node.client(t), rejected, policy, stuck)
+		assert.Nil(t, err)
+		assert.Equal(t, from.Hex(), replacement.From)
+		assert.Equal(t, []string{tx.Hash().Hex()}, replacement.ReplacedTxIDs)
+		assert.Equal(t, int64(1_200), replacement.Tx.GasPrice().Int64())
+	})
+
+	t.Run("dropped", func(t *testing.T) {
+		node := newStubEth()
+		node.setNonce(from, 7, 7)
+
+		// the dropped transaction is broadcast again
+		_, _, err := SyncReplaceableTx(ctx, node.client(t), info, policy, now)
+		assert.Equal(t, env.ErrWaitMessageOnChain.Error(), err.Error())
+		sent := node.sentTxs()
+		assert.Equal(t, 1, len(sent))
+		assert.Equal(t, tx.Hash(), sent[0].Hash())
+	})
+
+	t.Run("nonce used", func(t *testing.T) {
+		node := newStubEth()
+		node.setNonce(from, 8, 8)
+
+		_, _, err := SyncReplaceableTx(ctx, node.client(t), info, policy, stuck)
+		assert.Equal(t, ErrNonceUsed.Error(), err.Error())
+		assert.Equal(t, 0, len(node.sentTxs()))
+	})
+
+	t.Run("old version", func(t *testing.T) {
+		node := newStubEth()
+
+		_, _, err := SyncReplaceableTx(ctx, node.client(t), &ct.BroadcastInfo{TxID: tx.Hash().Hex()}, policy, stuck)
+		assert.Equal(t, ethereum.NotFound, err)
+	})
+}
@@ -0,0 +1,216 @@
+package eth
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var stubChainID = big.NewInt(1337)
+
+// stubEth the in process eth node of the tests
+type stubEth struct {
+	mu            sync.Mutex
+	header        *types.Header
+	gasPrice      *big.Int
+	tipCap        *big.Int
+	nonces        map[common.Address]uint64
+	pendingNonces map[common.Address]uint64
+	balances      map[common.Address]*big.Int
+	txs           map[common.Hash]*types.Transaction
+	receipts      map[common.Hash]*types.Receipt
+	sendErr       error
+	sent          []*types.Transaction
+}
+
+func newStubEth() *stubEth {
+	return &stubEth{
+		header:        &types.Header{Number: big.NewInt(100), Difficulty: big.NewInt(0)},
+		gasPrice:      big.NewInt(10),
+		tipCap:        big.NewInt(2),
+		nonces:        map[common.Address]uint64{},
+		pendingNonces: map[common.Address]uint64{},
+		balances:      map[common.Address]*big.Int{},
+		txs:           map[common.Hash]*types.Transaction{},
+		receipts:      map[common.Hash]*types.Receipt{},
+	}
+}
+
+func (s *stubEth) client(t *testing.T) *ethclient.Client {
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", s); err != nil {
+		t.Fatal(err)
+	}
+	c := rpc.DialInProc(server)
+	t.Cleanup(func() {
+		c.Close()
+		server.Stop()
+	})
+	return ethclient.NewClient(c)
+}
+
+func (s *stubEth) ChainId() *hexutil.Big { //nolint:stylecheck
+	return (*hexutil.Big)(stubChainID)
+}
+
+func (s *stubEth) GetBlockByNumber(number rpc.BlockNumber, full bool) (*types.Header, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.header, nil
+}
+
+func (s *stubEth) BlockNumber() hexutil.Uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return hexutil.Uint64(s.header.Number.Uint64())
+}
+
+func (s *stubEth) GasPrice() *hexutil.Big {
+	return (*hexutil.Big)(s.gasPrice)
+}
+
+func (s *stubEth) MaxPriorityFeePerGas() *hexutil.Big {
+	return (*hexutil.Big)(s.tipCap)
+}
+
+func (s *stubEth) GetTransactionCount(account common.Address, number rpc.BlockNumberOrHash) hexutil.Uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if n, ok := number.Number(); ok && n == rpc.PendingBlockNumber {
+		if nonce, ok := s.pendingNonces[account]; ok {
+			return hexutil.Uint64(nonce)
+		}
+	}
+	return hexutil.Uint64(s.nonces[account])
+}
+
+func (s *stubEth) GetBalance(account common.Address, number rpc.BlockNumberOrHash) *hexutil.Big {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	balance, ok := s.balances[account]
+	if !ok {
+		return (*hexutil.Big)(big.NewInt(0))
+	}
+	return (*hexutil.Big)(balance)
+}
+
+func (s *stubEth) GetTransactionByHash(hash common.Hash) (map[string]interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, ok := s.txs[hash]
+	if !ok {
+		return nil, nil
+	}
+	data, err := tx.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	rpcTx := map[string]interface{}{}
+	if err := json.Unmarshal(data, &rpcTx); err != nil {
+		return nil, err
+	}
+	// the mined transaction has the block number
+	if receipt, ok := s.receipts[hash]; ok {
+		rpcTx["blockNumber"] = (*hexutil.Big)(receipt.BlockNumber)
+	}
+	return rpcTx, nil
+}
+
+func (s *stubEth) GetTransactionReceipt(hash common.Hash) *types.Receipt {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.receipts[hash]
+}
+
+func (s *stubEth) SendRawTransaction(data hexutil.Bytes) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := rlp.Decode(bytes.NewReader(data), tx); err != nil {
+		return common.Hash{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, tx)
+	if s.sendErr != nil {
+		return common.Hash{}, s.sendErr
+	}
+	return tx.Hash(), nil
+}
+
+// addTx the pending transaction, it is mined with the status if the status is not nil
+func (s *stubEth) addTx(tx *types.Transaction, status *uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.txs[tx.Hash()] = tx
+	if status != nil {
+		s.receipts[tx.Hash()] = &types.Receipt{
+			Status:      *status,
+			TxHash:      tx.Hash(),
+			BlockNumber: s.header.Number,
+			Logs:        []*types.Log{},
+		}
+	}
+}
+
+func (s *stubEth) setBalance(account common.Address, balance *big.Int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.balances[account] = balance
+}
+
+func (s *stubEth) sentTxs() []*types.Transaction {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*types.Transaction(nil), s.sent...)
+}
+
+func (s *stubEth) setNonce(account common.Address, latest, pending uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nonces[account] = latest
+	s.pendingNonces[account] = pending
+}
+
+// stubSign sign the transaction by the test account
+func stubSign(t *testing.T, tx *types.Transaction) (*types.Transaction, common.Address) {
+	key, err := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signedTx, err := types.SignTx(tx, types.LatestSignerForChainID(stubChainID), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signedTx, crypto.PubkeyToAddress(key.PublicKey)
+}
+
+// stubTx the signed transaction of the test account
+func stubTx(t *testing.T, nonce uint64, gasPrice int64) (*types.Transaction, common.Address) {
+	return stubSign(t, types.NewTx(&types.LegacyTx{
+		Nonce:    nonce,
+		To:       &common.Address{1},
+		Value:    big.NewInt(1),
+		Gas:      TransferGasLimit,
+		GasPrice: big.NewInt(gasPrice),
+	}))
+}
+
+// stubRLP the rlp encoded transaction
+func stubRLP(t *testing.T, tx *types.Transaction) []byte {
+	data, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
@@ -13,10 +13,17 @@ type PreSignData struct {
 	From     string                `json:"from"`
 	ChainID  *big.Int              `json:"chain_id"`
 	Tx       *types.Transaction    `json:"tx"`
+	// the recipient has code, the gas limit of the native transfer is estimated rather
+	// than the transfer gas
+	ToContract bool   `json:"to_contract,omitempty"`
+	GasLimit   uint64 `json:"gas_limit,omitempty"`
+	// set when Tx replace the stuck transactions
+	ReplacedTxIDs []string `json:"replaced_tx_ids,omitempty"`
 }
 
 type SignedData struct {
-	SignedTx []byte `json:"signed_tx"`
+	SignedTx      []byte   `json:"signed_tx"`
+	ReplacedTxIDs []string `json:"replaced_tx_ids,omitempty"`
 }
 
 type BroadcastedData struct {
@@ -5,29 +5,23 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"math"
 	"math/big"
 
 	"github.com/NpoolPlatform/go-service-framework/pkg/logger"
 	"github.com/NpoolPlatform/message/npool/sphinxplugin"
 	"github.com/NpoolPlatform/message/npool/sphinxproxy"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins"
-	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/bsc"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/eth"
 	eth_plugin "github.com/NpoolPlatform/sphinx-plugin/pkg/coins/eth/eth"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/register"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/env"
-	"github.com/NpoolPlatform/sphinx-plugin/pkg/log"
 	plugin_types "github.com/NpoolPlatform/sphinx-plugin/pkg/types"
 	ethereum "github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 )
 
-const USDCACCURACY = 6
-
 // here register plugin func
 func init() {
 	register.RegisteTokenHandler(
@@ -55,13 +49,18 @@ func init() {
 		register.OpSyncTx,
 		eth_plugin.SyncTxState,
 	)
+	register.RegisteTokenHandler(
+		coins.USDC,
+		register.OpPreSignRelease,
+		eth.ReleasePreSign,
+	)
 
-	err := register.RegisteAbortFuncErr(sphinxplugin.CoinType_CoinTypeusdcerc20, bsc.TxFailErr)
+	err := register.RegisteErrorClassifier(sphinxplugin.CoinType_CoinTypeusdcerc20, eth.ClassifyErr)
 	if err != nil {
 		panic(err)
 	}
 
-	err = register.RegisteAbortFuncErr(sphinxplugin.CoinType_CoinTypetusdcerc20, bsc.TxFailErr)
+	err = register.RegisteErrorClassifier(sphinxplugin.CoinType_CoinTypetusdcerc20, eth.ClassifyErr)
 	if err != nil {
 		panic(err)
 	}
@@ -73,7 +72,7 @@ type BigUSDC struct {
 }
 
 func USDCBalance(ctx context.Context, addr string, client *ethclient.Client) (*BigUSDC, error) {
-	chainID, err := client.NetworkID(ctx)
+	chainID, err := client.ChainID(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -136,21 +135,7 @@ func walletBalance(ctx context.Context, in []byte, token *coins.TokenInfo) (out
 		return nil, fmt.Errorf("get erc20balance failed,%v", err)
 	}
 
-	balance, ok := big.NewFloat(0).SetString(bl.Balance.String())
-	if !ok {
-		return nil, errors.New("convert balance string to float64 error")
-	}
-
-	balance.Quo(balance, big.NewFloat(math.Pow10(USDCACCURACY)))
-	f, exact := balance.Float64()
-	if exact != big.Exact {
-		log.Warnf("wallet balance transfer warning balance from->to %v-%v", balance.String(), f)
-	}
-
-	wbResp := &plugin_types.WalletBalanceResponse{
-		Balance:    f,
-		BalanceStr: balance.String(),
-	}
+	wbResp := plugin_types.NewWalletBalanceResponse(plugin_types.NewAmountFromAtomic(bl.Balance, int(bl.Decimal)))
 	out, err = json.Marshal(wbResp)
 
 	return out, err
@@ -271,13 +256,11 @@ func preSign(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []
 		return nil, env.ErrAddressInvalid
 	}
 
-	amount := big.NewFloat(baseInfo.Value)
-	amount.Mul(amount, big.NewFloat(math.Pow10(tokenInfo.Decimal)))
-
-	amountBig, ok := big.NewInt(0).SetString(amount.Text('f', 0), 10)
-	if !ok {
-		return in, errors.New("invalid usd amount")
+	amount, err := baseInfo.GetAmount(tokenInfo.Decimal)
+	if err != nil {
+		return nil, err
 	}
+	amountBig := amount.BigInt()
 
 	_abi, err := Usdcv21MetaData.GetAbi()
 	if err != nil {
@@ -304,25 +287,20 @@ func preSign(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []
 		ethBalance  *big.Int
 		nonce       uint64
 		estimateGas uint64
-		gasPrice    *big.Int
+		fee         *eth.DynamicFee
 	)
 
 	err = client.WithClient(ctx, func(ctx context.Context, cli *ethclient.Client) (bool, error) {
-		chainID, err = cli.NetworkID(ctx)
+		chainID, err = cli.ChainID(ctx)
 		if err != nil || chainID == nil {
 			return true, err
 		}
 
-		nonce, err = cli.PendingNonceAt(ctx, common.HexToAddress(baseInfo.From))
+		fee, err = eth.SuggestDynamicFee(ctx, cli)
 		if err != nil {
 			return true, err
 		}
 
-		gasPrice, err = cli.SuggestGasPrice(ctx)
-		if err != nil || gasPrice == nil {
-			return true, err
-		}
-
 		ethBalance, err = cli.BalanceAt(ctx, common.HexToAddress(baseInfo.From), nil)
 		if err != nil || ethBalance == nil {
 			return true, err
@@ -346,13 +324,12 @@ func preSign(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []
 		return nil, err
 	}
 
-	if ethBalance == nil || gasPrice == nil {
+	if ethBalance == nil || fee == nil {
 		return nil, errors.New(eth.GetInfoFailed)
 	}
 
 	estimateGas = uint64(float64(estimateGas) * eth.GasTolerance)
-	estimateGasBig := big.NewInt(int64(estimateGas))
-	estimateFee := big.NewInt(0).Mul(gasPrice, estimateGasBig)
+	estimateFee := fee.MaxFee(estimateGas)
 
 	if ethBalance.Cmp(estimateFee) <= 0 {
 		logger.Sugar().Warnf("from %v, estimate fee >= balance: %v >= %v",
@@ -374,20 +351,26 @@ func preSign(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []
 		return nil, env.ErrContractInvalid
 	}
 
+	nonce, err = eth.ReserveNonce(ctx, client, chainID, baseInfo.From)
+	if err != nil {
+		return nil, err
+	}
+
 	// build tx
-	tx := types.NewTransaction(
+	tx := fee.NewTx(
+		chainID,
 		nonce,
 		common.HexToAddress(tokenInfo.Contract),
 		big.NewInt(0),
 		estimateGas,
-		big.NewInt(gasPrice.Int64()),
 		input,
 	)
 
 	info := &eth.PreSignData{
-		ChainID: chainID,
-		From:    baseInfo.From,
-		Tx:      tx,
+		ChainID:  chainID,
+		From:     baseInfo.From,
+		Tx:       tx,
+		GasLimit: estimateGas,
 	}
 
 	out, err = json.Marshal(info)
@@ -0,0 +1,79 @@
+package fil
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/env"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/filecoin-project/go-address"
+)
+
+const (
+	// EAMActorID the namespace of the f410 address
+	EAMActorID = uint64(10)
+	// MethodInvokeEVM the frc42 method number of InvokeEVM, it is accepted by the
+	// evm, eth account and placeholder actors
+	MethodInvokeEVM = uint64(3844450837)
+)
+
+var (
+	// InvokeEVMParams the cbor encoded empty bytes, value transfer without calldata
+	InvokeEVMParams = []byte{0x40}
+
+	// the eth address 0xff00..00<id> is the masked id address
+	maskedIDPrefix = append([]byte{0xff}, make([]byte, 11)...)
+)
+
+// FILNetPrefix the address prefix of the net
+var FILNetPrefix = map[string]string{
+	coins.CoinNetMain: address.MainnetPrefix,
+	coins.CoinNetTest: address.TestnetPrefix,
+}
+
+// NewAddress parse the address of the net, the address of the other net is rejected,
+// it never touch the address.CurrentNetwork which is shared by all the requests,
+// the 0x address is converted to the f410 address
+func NewAddress(addr, net string) (address.Address, error) {
+	prefix, ok := FILNetPrefix[net]
+	if !ok {
+		return address.Undef, env.ErrEVNCoinNetValue
+	}
+	if common.IsHexAddress(addr) && strings.HasPrefix(addr, "0x") {
+		return NewAddressFromEth(common.HexToAddress(addr))
+	}
+	if !strings.HasPrefix(addr, prefix) {
+		return address.Undef, fmt.Errorf("%v,%v is not %v address", env.ErrAddressInvalid, addr, net)
+	}
+	return address.NewFromString(addr)
+}
+
+// EncodeAddress format the address with the prefix of the net
+func EncodeAddress(addr address.Address, net string) (string, error) {
+	prefix, ok := FILNetPrefix[net]
+	if !ok {
+		return "", env.ErrEVNCoinNetValue
+	}
+	if addr == address.Undef {
+		return "", env.ErrAddressInvalid
+	}
+	// only the first byte is decided by the net, the rest is the same
+	return prefix + addr.String()[len(prefix):], nil
+}
+
+// NewAddressFromEth convert the eth address to the f410 address, the masked id
+// address is converted to the f0 address
+func NewAddressFromEth(ethAddr common.Address) (address.Address, error) {
+	if bytes.HasPrefix(ethAddr.Bytes(), maskedIDPrefix) {
+		return address.NewIDAddress(binary.BigEndian.Uint64(ethAddr.Bytes()[len(maskedIDPrefix):]))
+	}
+	return address.NewDelegatedAddress(EAMActorID, ethAddr.Bytes())
+}
+
+// IsEVMAddress the recipient is the evm actor, eth account or placeholder
+func IsEVMAddress(addr address.Address) bool {
+	return addr.Protocol() == address.Delegated
+}
@@ -0,0 +1,75 @@
+package fil
+
+import (
+	"crypto/rand"
+	"sync"
+	"testing"
+
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins"
+	"github.com/filecoin-project/go-address"
+	"github.com/test-go/testify/assert"
+)
+
+// run with -race, the main and test requests are handled concurrently
+func TestAddressConcurrentNet(t *testing.T) {
+	const requests = 100
+
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		for _, net := range []string{coins.CoinNetMain, coins.CoinNetTest} {
+			wg.Add(1)
+			go func(net string) {
+				defer wg.Done()
+
+				pubKey := make([]byte, 65)
+				_, err := rand.Read(pubKey)
+				assert.Nil(t, err)
+
+				addr, err := address.NewSecp256k1Address(pubKey)
+				assert.Nil(t, err)
+
+				str, err := EncodeAddress(addr, net)
+				assert.Nil(t, err)
+				assert.Equal(t, FILNetPrefix[net], str[:1])
+
+				parsed, err := NewAddress(str, net)
+				assert.Nil(t, err)
+				assert.Equal(t, addr, parsed)
+			}(net)
+		}
+	}
+	wg.Wait()
+
+	addr, err := address.NewIDAddress(1000)
+	assert.Nil(t, err)
+
+	str, err := EncodeAddress(addr, coins.CoinNetMain)
+	assert.Nil(t, err)
+	assert.Equal(t, "f01000", str)
+
+	_, err = NewAddress(str, coins.CoinNetTest)
+	assert.NotNil(t, err)
+}
+
+func TestNewAddressFromEth(t *testing.T) {
+	addr, err := NewAddress("0xd388ab098ed3e84c0d808776440b48f685198498", coins.CoinNetMain)
+	assert.Nil(t, err)
+	assert.True(t, IsEVMAddress(addr))
+
+	str, err := EncodeAddress(addr, coins.CoinNetMain)
+	assert.Nil(t, err)
+	assert.Equal(t, "f410f2oekwcmo2pueydmaq53eic2i62crtbeyuzx2gmy", str)
+
+	parsed, err := NewAddress(str, coins.CoinNetMain)
+	assert.Nil(t, err)
+	assert.Equal(t, addr, parsed)
+
+	// the masked id address
+	addr, err = NewAddress("0xff000000000000000000000000000000000003e8", coins.CoinNetTest)
+	assert.Nil(t, err)
+	assert.False(t, IsEVMAddress(addr))
+
+	str, err = EncodeAddress(addr, coins.CoinNetTest)
+	assert.Nil(t, err)
+	assert.Equal(t, "t01000", str)
+}
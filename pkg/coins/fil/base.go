@@ -1,25 +1,14 @@
 package fil
 
 import (
-	"strings"
-
 	v1 "github.com/NpoolPlatform/message/npool/basetypes/v1"
 	"github.com/NpoolPlatform/message/npool/sphinxplugin"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/register"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/env"
-	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-state-types/crypto"
-	"github.com/filecoin-project/lotus/build"
-	"github.com/shopspring/decimal"
 )
 
-// FILNetMap fil net map
-var FILNetMap = map[string]address.Network{
-	coins.CoinNetMain: address.Mainnet,
-	coins.CoinNetTest: address.Testnet,
-}
-
 const (
 	ChainType           = sphinxplugin.ChainType_Filecoin
 	ChainNativeUnit     = "FIL"
@@ -30,9 +19,12 @@ const (
 )
 
 var (
-	FilTxFailed = `fil tx failed`
-	filNonceLow = `message nonce too low`
-	stopErrMsg  = []string{FilTxFailed, filNonceLow}
+	FilTxFailed   = `fil tx failed`
+	filNonceLow   = `message nonce too low`
+	errorMessages = []register.ErrorMessage{
+		{Message: FilTxFailed, Kind: register.ErrorKindPermanent},
+		{Message: filNonceLow, Kind: register.ErrorKindPermanent},
+	}
 
 	filecoinToken = &coins.TokenInfo{OfficialName: "Filecoin", Decimal: 18, Unit: "FIL", Name: ChainNativeCoinName, OfficialContract: ChainNativeCoinName, TokenType: coins.Filecoin}
 )
@@ -43,7 +35,7 @@ func init() {
 	filecoinToken.ChainNativeUnit = ChainNativeUnit
 	filecoinToken.ChainAtomicUnit = ChainAtomicUnit
 	filecoinToken.ChainUnitExp = ChainUnitExp
-	filecoinToken.GasType = v1.GasType_GasUnsupported
+	filecoinToken.GasType = v1.GasType_DynamicGas
 	filecoinToken.ChainID = ChainID
 	filecoinToken.ChainNickname = ChainType.String()
 	filecoinToken.ChainNativeCoinName = ChainNativeCoinName
@@ -66,28 +58,11 @@ func SignType(signType string) (crypto.SigType, error) {
 	}
 }
 
-// FIL2AttoFIL not used, at sphinx sign deal
-func FIL2AttoFIL(value float64) (float64, bool) {
-	return decimal.NewFromFloat(value).
-		Mul(decimal.NewFromInt(int64(build.FilecoinPrecision))).
-		Float64()
-}
-
-func AttoFIL2FIL(value float64) (float64, bool) {
-	return decimal.NewFromFloat(value).
-		Div(decimal.NewFromInt(int64(build.FilecoinPrecision))).
-		Float64()
+// ClassifyErr the kind of the node error
+func ClassifyErr(err error) register.ErrorKind {
+	return register.MessageClassifier(errorMessages...)(err)
 }
 
 func TxFailErr(err error) bool {
-	if err == nil {
-		return false
-	}
-
-	for _, v := range stopErrMsg {
-		if strings.Contains(err.Error(), v) {
-			return true
-		}
-	}
-	return false
+	return ClassifyErr(err).Abort()
 }
@@ -0,0 +1,19 @@
+package fil
+
+import (
+	"strings"
+)
+
+// the same message is pushed by the last broadcast, the replace by fee error is
+// `already in mpool, increase GasPremium ...` which is not matched
+const alreadyInMpool = `already in mpool: `
+
+// AlreadyInMpoolErr the resent message is already in the message pool
+func AlreadyInMpoolErr(err error) bool {
+	return err != nil && strings.Contains(err.Error(), alreadyInMpool)
+}
+
+// NonceTooLowErr the nonce of the message is used, it may be used by the message itself
+func NonceTooLowErr(err error) bool {
+	return err != nil && strings.Contains(err.Error(), filNonceLow)
+}
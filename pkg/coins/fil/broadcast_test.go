@@ -0,0 +1,18 @@
+package fil
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/test-go/testify/assert"
+)
+
+func TestAlreadyInMpoolErr(t *testing.T) {
+	assert.False(t, AlreadyInMpoolErr(nil))
+	assert.True(t, AlreadyInMpoolErr(errors.New("message from f1abc with nonce 3 already in mpool: validation failure")))
+	// the replace by fee error is not the same message
+	assert.False(t, AlreadyInMpoolErr(errors.New("message from f1abc with nonce 3 already in mpool, increase GasPremium to 120 from 100 to trigger replace by fee")))
+
+	assert.True(t, NonceTooLowErr(errors.New("mpool push: message nonce too low")))
+	assert.True(t, TxFailErr(errors.New("mpool push: message nonce too low")))
+}
@@ -143,6 +143,14 @@ func (fClients *FClients) WithClient(ctx context.Context, fn func(c v0api.FullNo
 	return err
 }
 
+// fClient the lotus client of the handlers, it is replaced by the stub node in the tests
+var fClient FClientI = &FClients{}
+
 func Client() FClientI {
-	return &FClients{}
+	return fClient
+}
+
+// SetClient replace the lotus client of the handlers
+func SetClient(cli FClientI) {
+	fClient = cli
 }
@@ -0,0 +1,60 @@
+package fil
+
+import (
+	"context"
+	"errors"
+
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/config"
+	ct "github.com/NpoolPlatform/sphinx-plugin/pkg/types"
+	"github.com/filecoin-project/go-state-types/abi"
+	lotusapi "github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/api/v0api"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+const (
+	// SendGasLimit the gas limit of the estimate-gas handler, a simple send
+	// to the new account costs about 1.5M gas
+	SendGasLimit = int64(2_000_000)
+	// EstimateInclBlocks the blocks expected to be included
+	EstimateInclBlocks = 10
+	// EstimateQueueBlocks the blocks the message can wait in the mpool
+	EstimateQueueBlocks = 20
+)
+
+var ErrGasOverflow = errors.New("fil gas value overflow")
+
+// MaxFee the upper limit of the message fee(GasFeeCap * GasLimit), zero means
+// use the default of the lotus node
+func MaxFee() abi.TokenAmount {
+	envInfo := config.GetENV()
+	if envInfo == nil || envInfo.FilMaxFee <= 0 {
+		return abi.NewTokenAmount(0)
+	}
+
+	maxFee, err := ct.NewAmountFromFloat(envInfo.FilMaxFee, ChainUnitExp)
+	if err != nil {
+		return abi.NewTokenAmount(0)
+	}
+	return abi.TokenAmount{Int: maxFee.BigInt()}
+}
+
+// EstimateMessageGas fill the gas fields of the message by the lotus node,
+// the fee is limited by the MaxFee
+func EstimateMessageGas(ctx context.Context, cli v0api.FullNode, msg *types.Message) (*types.Message, error) {
+	estimated, err := cli.GasEstimateMessageGas(
+		ctx,
+		msg,
+		&lotusapi.MessageSendSpec{MaxFee: MaxFee()},
+		types.EmptyTSK,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// RawTx carry the fee cap and premium as int64
+	if !estimated.GasFeeCap.IsInt64() || !estimated.GasPremium.IsInt64() {
+		return nil, ErrGasOverflow
+	}
+	return estimated, nil
+}
@@ -3,28 +3,24 @@ package plugin
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"math/big"
 
 	"github.com/NpoolPlatform/message/npool/sphinxplugin"
+	"github.com/NpoolPlatform/message/npool/sphinxproxy"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/fil"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/register"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/env"
-	"github.com/NpoolPlatform/sphinx-plugin/pkg/log"
 	ct "github.com/NpoolPlatform/sphinx-plugin/pkg/types"
 	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/go-state-types/crypto"
 	lotus_api "github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/api/v0api"
-	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/specs-actors/actors/builtin"
 	"github.com/ipfs/go-cid"
-
-	"github.com/shopspring/decimal"
 )
 
 // here register plugin func
@@ -49,13 +45,18 @@ func init() {
 		register.OpSyncTx,
 		syncTx,
 	)
+	register.RegisteTokenHandler(
+		coins.Filecoin,
+		register.OpEstimateGas,
+		estimateGas,
+	)
 
-	err := register.RegisteAbortFuncErr(sphinxplugin.CoinType_CoinTypefilecoin, fil.TxFailErr)
+	err := register.RegisteErrorClassifier(sphinxplugin.CoinType_CoinTypefilecoin, fil.ClassifyErr)
 	if err != nil {
 		panic(err)
 	}
 
-	err = register.RegisteAbortFuncErr(sphinxplugin.CoinType_CoinTypetfilecoin, fil.TxFailErr)
+	err = register.RegisteErrorClassifier(sphinxplugin.CoinType_CoinTypetfilecoin, fil.ClassifyErr)
 	if err != nil {
 		panic(err)
 	}
@@ -67,22 +68,15 @@ func walletBalance(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (
 		return nil, err
 	}
 
-	v, ok := env.LookupEnv(env.ENVCOINNET)
-	if !ok {
-		return nil, env.ErrEVNCoinNet
-	}
-	if !coins.CheckSupportNet(v) {
+	if !coins.CheckSupportNet(tokenInfo.Net) {
 		return nil, env.ErrEVNCoinNetValue
 	}
 
-	// TODO in main init
-	address.CurrentNetwork = fil.FILNetMap[v]
-
 	if info.Address == "" {
 		return nil, env.ErrAddressInvalid
 	}
 
-	from, err := address.NewFromString(info.Address)
+	from, err := fil.NewAddress(info.Address, tokenInfo.Net)
 	if err != nil {
 		return nil, err
 	}
@@ -101,23 +95,62 @@ func walletBalance(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (
 		return nil, err
 	}
 
-	balance, ok := big.NewFloat(0).SetString(chainBalance.String())
-	if !ok {
-		return nil, errors.New("convert balance string to float64 error")
+	_out := ct.NewWalletBalanceResponse(ct.NewAmountFromAtomic(chainBalance.Int, tokenInfo.Decimal))
+
+	return json.Marshal(_out)
+}
+
+func estimateGas(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []byte, err error) {
+	esGasReq := &sphinxproxy.GetEstimateGasRequest{}
+	err = json.Unmarshal(in, esGasReq)
+	if err != nil {
+		return nil, err
 	}
 
-	balance.Quo(balance, big.NewFloat(0).SetUint64(build.FilecoinPrecision))
-	f, exact := balance.Float64()
-	if exact != big.Exact {
-		log.Warnf("wallet balance transfer warning balance from->to %v-%v", balance.String(), f)
+	api := fil.Client()
+	var (
+		head       *types.TipSet
+		gasPremium types.BigInt
+		gasFeeCap  types.BigInt
+	)
+	err = api.WithClient(ctx, func(cli v0api.FullNode) (bool, error) {
+		head, err = cli.ChainHead(ctx)
+		if err != nil {
+			return true, err
+		}
+
+		// the sender is not used to estimate the premium
+		gasPremium, err = cli.GasEstimateGasPremium(ctx, fil.EstimateInclBlocks, address.Undef, fil.SendGasLimit, types.EmptyTSK)
+		if err != nil {
+			return true, err
+		}
+
+		gasFeeCap, err = cli.GasEstimateFeeCap(ctx, &types.Message{
+			GasLimit:   fil.SendGasLimit,
+			GasPremium: gasPremium,
+		}, fil.EstimateQueueBlocks, types.EmptyTSK)
+		if err != nil {
+			return true, err
+		}
+		return false, err
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	_out := ct.WalletBalanceResponse{
-		Balance:    f,
-		BalanceStr: balance.String(),
+	estimateFee := big.NewInt(0).Mul(gasFeeCap.Int, big.NewInt(fil.SendGasLimit))
+	if maxFee := fil.MaxFee(); maxFee.Sign() > 0 && estimateFee.Cmp(maxFee.Int) > 0 {
+		estimateFee = maxFee.Int
 	}
 
-	return json.Marshal(_out)
+	wbResp := &sphinxproxy.GetEstimateGasResponse{
+		GasLimit:  fmt.Sprint(fil.SendGasLimit),
+		GasPrice:  gasFeeCap.String(),
+		Fee:       ct.NewAmountFromAtomic(estimateFee, tokenInfo.Decimal).String(),
+		TipsPrice: gasPremium.String(),
+		BlockNum:  uint64(head.Height()),
+	}
+	return json.Marshal(wbResp)
 }
 
 func preSign(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []byte, err error) {
@@ -130,18 +163,25 @@ func preSign(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []
 		return nil, env.ErrEVNCoinNetValue
 	}
 
-	// TODO in main init
-	address.CurrentNetwork = fil.FILNetMap[info.ENV]
-
 	if info.From == "" {
 		return nil, env.ErrAddressInvalid
 	}
 
-	from, err := address.NewFromString(info.From)
+	from, err := fil.NewAddress(info.From, tokenInfo.Net)
 	if err != nil {
 		return nil, err
 	}
 
+	amount, err := info.GetAmount(tokenInfo.Decimal)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := fil.NewAddress(info.To, tokenInfo.Net)
+	if err != nil {
+		return nil, env.ErrAddressInvalid
+	}
+
 	api := fil.Client()
 	var _nonce uint64
 	err = api.WithClient(ctx, func(cli v0api.FullNode) (bool, error) {
@@ -155,16 +195,49 @@ func preSign(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []
 		return nil, err
 	}
 
+	msg := &types.Message{
+		To:     to,
+		From:   from,
+		Nonce:  _nonce,
+		Value:  abi.TokenAmount{Int: amount.BigInt()},
+		Method: builtin.MethodSend,
+	}
+	// send to the evm actor by InvokeEVM, so the fallback of the contract is called
+	if fil.IsEVMAddress(to) {
+		msg.Method = abi.MethodNum(fil.MethodInvokeEVM)
+		msg.Params = fil.InvokeEVMParams
+	}
+
+	// the 0x recipient is converted to the f410 address
+	toAddr, err := fil.EncodeAddress(to, tokenInfo.Net)
+	if err != nil {
+		return nil, err
+	}
+
+	var estimated *types.Message
+	err = api.WithClient(ctx, func(cli v0api.FullNode) (bool, error) {
+		estimated, err = fil.EstimateMessageGas(ctx, cli, msg)
+		if err != nil {
+			return true, err
+		}
+		return false, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	_out := fil.SignRequest{
 		ENV: info.ENV,
 		Info: fil.RawTx{
-			To:         info.To,
+			To:         toAddr,
 			From:       info.From,
 			Value:      info.Value,
-			GasLimit:   200000000,
-			GasFeeCap:  10000000,
-			GasPremium: 1000000,
-			Method:     uint64(builtin.MethodSend),
+			Amount:     amount.String(),
+			GasLimit:   estimated.GasLimit,
+			GasFeeCap:  estimated.GasFeeCap.Int64(),
+			GasPremium: estimated.GasPremium.Int64(),
+			Method:     uint64(msg.Method),
+			Params:     msg.Params,
 			Nonce:      _nonce,
 		},
 	}
@@ -185,15 +258,12 @@ func broadcast(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out
 		return nil, env.ErrEVNCoinNetValue
 	}
 
-	// TODO in main init
-	address.CurrentNetwork = fil.FILNetMap[info.ENV]
-
-	to, err := address.NewFromString(raw.To)
+	to, err := fil.NewAddress(raw.To, tokenInfo.Net)
 	if err != nil {
 		return nil, env.ErrAddressInvalid
 	}
 
-	from, err := address.NewFromString(raw.From)
+	from, err := fil.NewAddress(raw.From, tokenInfo.Net)
 	if err != nil {
 		return nil, env.ErrAddressInvalid
 	}
@@ -202,18 +272,20 @@ func broadcast(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out
 	if err != nil {
 		return nil, env.ErrSignTypeInvalid
 	}
-	val, err := types.ParseFIL(decimal.NewFromFloat(raw.Value).String())
+	amount, err := ct.ParseAmount(raw.Amount, raw.Value, tokenInfo.Decimal)
 	if err != nil {
 		return nil, err
 	}
 
 	signMsg := &types.SignedMessage{
 		Message: types.Message{
+			Version:    raw.Version,
 			To:         to,
 			From:       from,
 			Method:     abi.MethodNum(raw.Method),
+			Params:     raw.Params,
 			Nonce:      raw.Nonce,
-			Value:      abi.TokenAmount(val),
+			Value:      abi.TokenAmount{Int: amount.BigInt()},
 			GasLimit:   raw.GasLimit,
 			GasFeeCap:  abi.NewTokenAmount(raw.GasFeeCap),
 			GasPremium: abi.NewTokenAmount(raw.GasPremium),
@@ -224,10 +296,24 @@ func broadcast(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out
 		},
 	}
 
+	// the cid is known before broadcast, the resent message is not pushed twice
+	_cid := signMsg.Cid()
 	api := fil.Client()
-	var _cid cid.Cid
 	err = api.WithClient(ctx, func(cli v0api.FullNode) (bool, error) {
-		_cid, err = cli.MpoolPush(ctx, signMsg)
+		_, err = cli.MpoolPush(ctx, signMsg)
+		if fil.AlreadyInMpoolErr(err) {
+			return false, nil
+		}
+		if fil.NonceTooLowErr(err) {
+			lookup, _err := cli.StateSearchMsg(ctx, _cid)
+			if _err != nil {
+				return true, _err
+			}
+			if lookup != nil {
+				return false, nil
+			}
+			return false, err
+		}
 		if err != nil {
 			return true, err
 		}
@@ -250,17 +336,10 @@ func syncTx(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []b
 		return nil, err
 	}
 
-	v, ok := env.LookupEnv(env.ENVCOINNET)
-	if !ok {
-		return nil, env.ErrEVNCoinNet
-	}
-	if !coins.CheckSupportNet(v) {
+	if !coins.CheckSupportNet(tokenInfo.Net) {
 		return nil, env.ErrEVNCoinNetValue
 	}
 
-	// TODO in main init
-	address.CurrentNetwork = fil.FILNetMap[v]
-
 	_cid, err := cid.Decode(info.TxID)
 	if err != nil {
 		return nil, env.ErrCIDInvalid
@@ -301,22 +380,28 @@ func syncTx(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []b
 		return nil, env.ErrWaitMessageOnChain
 	}
 
+	// the receipt may belong to the replacement of the message, eg: the evm
+	// message is replaced with a higher premium
+	_out := ct.SyncResponse{
+		ExitCode: int64(chainMsg.Receipt.ExitCode),
+	}
+	if !chainMsg.Message.Equals(_cid) {
+		_out.TxID = chainMsg.Message.String()
+	}
+
 	if ok := chainMsg.Receipt.ExitCode.IsSuccess(); !ok {
-		_out := ct.SyncResponse{
-			ExitCode: int64(chainMsg.Receipt.ExitCode),
-		}
 		out, err := json.Marshal(_out)
 		if err != nil {
 			return nil, err
 		}
+		// the return of the reverted evm message is the revert data
+		if len(chainMsg.Receipt.Return) > 0 {
+			return out, fmt.Errorf("%v,%v,return: 0x%x", fil.FilTxFailed, chainMsg.Receipt.ExitCode.Error(), chainMsg.Receipt.Return)
+		}
 		return out, fmt.Errorf("%v,%v", fil.FilTxFailed, chainMsg.Receipt.ExitCode.Error())
 	}
 
 	// check message on chain done
-	_out := ct.SyncResponse{
-		ExitCode: int64(chainMsg.Receipt.ExitCode),
-	}
-
 	return json.Marshal(_out)
 }
 
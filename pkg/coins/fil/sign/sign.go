@@ -10,12 +10,9 @@ import (
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/register"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/env"
 	ct "github.com/NpoolPlatform/sphinx-plugin/pkg/types"
-	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-state-types/abi"
-	ftypes "github.com/filecoin-project/lotus/chain/types"
 	"github.com/myxtype/filecoin-client/local"
 	"github.com/myxtype/filecoin-client/types"
-	"github.com/shopspring/decimal"
 )
 
 func init() {
@@ -33,6 +30,11 @@ func init() {
 
 const s3KeyPrxfix = "filecoin/"
 
+// getPrivateKey the private key of the address, it is replaced by the tests
+var getPrivateKey = func(ctx context.Context, addr string) ([]byte, error) {
+	return oss.GetObject(ctx, s3KeyPrxfix+addr, true)
+}
+
 // createAccount create new account address
 func createAccount(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []byte, err error) {
 	info := ct.NewAccountRequest{}
@@ -44,15 +46,15 @@ func createAccount(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (
 		return nil, env.ErrEVNCoinNetValue
 	}
 
-	// set current net type main or test
-	address.CurrentNetwork = fil.FILNetMap[info.ENV]
-
 	ki, _addr, err := local.WalletNew(types.KTSecp256k1)
 	if err != nil {
 		return nil, err
 	}
 
-	addr := _addr.String()
+	addr, err := fil.EncodeAddress(*_addr, tokenInfo.Net)
+	if err != nil {
+		return nil, err
+	}
 	_out := ct.NewAccountResponse{
 		Address: addr,
 	}
@@ -79,25 +81,21 @@ func signTx(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []b
 		return nil, env.ErrEVNCoinNetValue
 	}
 
-	// set current net type main or test
-	address.CurrentNetwork = fil.FILNetMap[info.ENV]
-
-	to, err := address.NewFromString(raw.To)
+	to, err := fil.NewAddress(raw.To, tokenInfo.Net)
 	if err != nil {
 		return nil, err
 	}
-	from, err := address.NewFromString(raw.From)
+	from, err := fil.NewAddress(raw.From, tokenInfo.Net)
 	if err != nil {
 		return nil, err
 	}
 
-	// big.NewFloat(raw.Value).String()/Text()
-	val, err := ftypes.ParseFIL(decimal.NewFromFloat(raw.Value).String())
+	amount, err := ct.ParseAmount(raw.Amount, raw.Value, tokenInfo.Decimal)
 	if err != nil {
 		return nil, err
 	}
 
-	pk, err := oss.GetObject(ctx, s3KeyPrxfix+raw.From, true)
+	pk, err := getPrivateKey(ctx, raw.From)
 	if err != nil {
 		return
 	}
@@ -107,7 +105,7 @@ func signTx(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []b
 		To:         to,
 		From:       from,
 		Nonce:      raw.Nonce,
-		Value:      abi.TokenAmount(val),
+		Value:      abi.TokenAmount{Int: amount.BigInt()},
 		GasLimit:   raw.GasLimit,
 		GasFeeCap:  abi.NewTokenAmount(raw.GasFeeCap),
 		GasPremium: abi.NewTokenAmount(raw.GasPremium),
@@ -0,0 +1,194 @@
+package sign
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/fil"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/register"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/endpoints"
+	ct "github.com/NpoolPlatform/sphinx-plugin/pkg/types"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-jsonrpc"
+	lotusapi "github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/api/v0api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/ipfs/go-cid"
+	"github.com/myxtype/filecoin-client/local"
+	filtypes "github.com/myxtype/filecoin-client/types"
+	"github.com/test-go/testify/assert"
+
+	// register the plugin handlers
+	_ "github.com/NpoolPlatform/sphinx-plugin/pkg/coins/fil/plugin"
+)
+
+// stubNode the lotus node of the handlers, the methods not used panic
+type stubNode struct {
+	v0api.FullNode
+
+	balances map[address.Address]types.BigInt
+	nonces   map[address.Address]uint64
+
+	mu     sync.Mutex
+	pushed map[cid.Cid]*types.SignedMessage
+}
+
+func (n *stubNode) WalletBalance(ctx context.Context, addr address.Address) (types.BigInt, error) {
+	balance, ok := n.balances[addr]
+	if !ok {
+		return types.NewInt(0), nil
+	}
+	return balance, nil
+}
+
+func (n *stubNode) MpoolGetNonce(ctx context.Context, addr address.Address) (uint64, error) {
+	return n.nonces[addr], nil
+}
+
+func (n *stubNode) GasEstimateMessageGas(
+	ctx context.Context,
+	msg *types.Message,
+	spec *lotusapi.MessageSendSpec,
+	tsk types.TipSetKey,
+) (*types.Message, error) {
+	estimated := *msg
+	estimated.GasLimit = 1_500_000
+	estimated.GasFeeCap = types.NewInt(200)
+	estimated.GasPremium = types.NewInt(100)
+	return &estimated, nil
+}
+
+func (n *stubNode) MpoolPush(ctx context.Context, msg *types.SignedMessage) (cid.Cid, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.pushed[msg.Cid()] = msg
+	return msg.Cid(), nil
+}
+
+// stubClient call the handler with the stub node, never dial the endpoints
+type stubClient struct {
+	node *stubNode
+}
+
+func (c *stubClient) GetNode(ctx context.Context, endpointmgr *endpoints.Manager) (v0api.FullNode, jsonrpc.ClientCloser, error) {
+	return c.node, func() {}, nil
+}
+
+func (c *stubClient) WithClient(ctx context.Context, fn func(v0api.FullNode) (bool, error)) error {
+	_, err := fn(c.node)
+	return err
+}
+
+type stubAccount struct {
+	net  string
+	addr address.Address
+	str  string
+}
+
+func callHandler(t *testing.T, op register.OpType, in interface{}, tokenInfo *coins.TokenInfo) []byte {
+	fn, ok := register.TokenHandlers[coins.Filecoin][op]
+	assert.True(t, ok)
+
+	req, err := json.Marshal(in)
+	assert.Nil(t, err)
+	out, err := fn(context.Background(), req, tokenInfo)
+	assert.Nil(t, err)
+	return out
+}
+
+// run with -race, the main and test requests are handled concurrently
+func TestHandlerConcurrentNet(t *testing.T) {
+	const accounts = 20
+
+	node := &stubNode{
+		balances: make(map[address.Address]types.BigInt),
+		nonces:   make(map[address.Address]uint64),
+		pushed:   make(map[cid.Cid]*types.SignedMessage),
+	}
+	keys := make(map[string][]byte)
+	tokenInfos := make(map[string]*coins.TokenInfo)
+	stubs := []stubAccount{}
+	for i := 0; i < accounts; i++ {
+		for _, net := range []string{coins.CoinNetMain, coins.CoinNetTest} {
+			ki, addr, err := local.WalletNew(filtypes.KTSecp256k1)
+			assert.Nil(t, err)
+
+			str, err := fil.EncodeAddress(*addr, net)
+			assert.Nil(t, err)
+			keys[str] = ki.PrivateKey
+			node.balances[*addr] = types.BigMul(types.NewInt(uint64(i+1)), types.NewInt(1_000_000_000_000_000_000))
+			node.nonces[*addr] = uint64(i)
+			stubs = append(stubs, stubAccount{net: net, addr: *addr, str: str})
+		}
+	}
+	for _, name := range []string{fil.ChainNativeCoinName, coins.TestPrefix + fil.ChainNativeCoinName} {
+		tokenInfo := register.NameToTokenInfo[name]
+		assert.NotNil(t, tokenInfo)
+		tokenInfos[tokenInfo.Net] = tokenInfo
+	}
+
+	fil.SetClient(&stubClient{node: node})
+	defer fil.SetClient(&fil.FClients{})
+	_getPrivateKey := getPrivateKey
+	defer func() { getPrivateKey = _getPrivateKey }()
+	getPrivateKey = func(ctx context.Context, addr string) ([]byte, error) {
+		key, ok := keys[addr]
+		if !ok {
+			return nil, fmt.Errorf("private key of %v not found", addr)
+		}
+		return key, nil
+	}
+
+	var wg sync.WaitGroup
+	for i, from := range stubs {
+		wg.Add(1)
+		go func(i int, from, to stubAccount) {
+			defer wg.Done()
+			tokenInfo := tokenInfos[from.net]
+
+			balance := ct.WalletBalanceResponse{}
+			out := callHandler(t, register.OpGetBalance, &ct.WalletBalanceRequest{Address: from.str}, tokenInfo)
+			assert.Nil(t, json.Unmarshal(out, &balance))
+			assert.Equal(t, fmt.Sprint(i/2+1), balance.BalanceStr)
+
+			signReq := fil.SignRequest{}
+			out = callHandler(t, register.OpPreSign, &ct.BaseInfo{ENV: from.net, From: from.str, To: to.str, Amount: "0.5"}, tokenInfo)
+			assert.Nil(t, json.Unmarshal(out, &signReq))
+			assert.Equal(t, from.str, signReq.Info.From)
+			assert.Equal(t, to.str, signReq.Info.To)
+			assert.Equal(t, uint64(i/2), signReq.Info.Nonce)
+
+			broadcastReq := fil.BroadcastRequest{}
+			out = callHandler(t, register.OpSign, &signReq, tokenInfo)
+			assert.Nil(t, json.Unmarshal(out, &broadcastReq))
+			assert.Equal(t, "secp256k1", broadcastReq.Signature.SignType)
+
+			syncReq := ct.SyncRequest{}
+			out = callHandler(t, register.OpBroadcast, &broadcastReq, tokenInfo)
+			assert.Nil(t, json.Unmarshal(out, &syncReq))
+
+			_cid, err := cid.Decode(syncReq.TxID)
+			assert.Nil(t, err)
+			node.mu.Lock()
+			msg, ok := node.pushed[_cid]
+			node.mu.Unlock()
+			assert.True(t, ok)
+			assert.Equal(t, from.addr, msg.Message.From)
+			assert.Equal(t, to.addr, msg.Message.To)
+		}(i, from, stubs[(i+2)%len(stubs)])
+	}
+	wg.Wait()
+	assert.Equal(t, len(stubs), len(node.pushed))
+
+	// the address of the other net is rejected
+	_, err := register.TokenHandlers[coins.Filecoin][register.OpGetBalance](
+		context.Background(),
+		[]byte(fmt.Sprintf(`{"address":"%v"}`, stubs[0].str)),
+		tokenInfos[coins.CoinNetTest],
+	)
+	assert.NotNil(t, err)
+}
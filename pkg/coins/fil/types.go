@@ -5,6 +5,7 @@ type RawTx struct {
 	To         string  `json:"to"`
 	From       string  `json:"from"`
 	Value      float64 `json:"value"`
+	Amount     string  `json:"amount,omitempty"`
 	Nonce      uint64  `json:"nonce"`
 	GasLimit   int64   `json:"gas_limit"`
 	GasFeeCap  int64   `json:"gas_fee_cap"`
@@ -16,6 +16,8 @@ import (
 	_ "github.com/NpoolPlatform/sphinx-plugin/pkg/coins/sol"
 	_ "github.com/NpoolPlatform/sphinx-plugin/pkg/coins/sol/plugin"
 	_ "github.com/NpoolPlatform/sphinx-plugin/pkg/coins/sol/sign"
+	_ "github.com/NpoolPlatform/sphinx-plugin/pkg/coins/sol/spl/plugin"
+	_ "github.com/NpoolPlatform/sphinx-plugin/pkg/coins/sol/spl/sign"
 
 	// register handle
 	_ "github.com/NpoolPlatform/sphinx-plugin/pkg/coins/btc"
@@ -83,29 +85,7 @@ func GetTokenNetHandler(coinType sphinxplugin.CoinType) (register.NetHandlerDef,
 	return fn, nil
 }
 
-func nextStop(err error) bool {
-	if err == nil {
-		return false
-	}
-
-	_, ok := register.AbortErrs[err]
-	return ok
-}
-
 // Abort ..
 func Abort(coinType sphinxplugin.CoinType, err error) bool {
-	if err == nil {
-		return false
-	}
-
-	if nextStop(err) {
-		return true
-	}
-
-	mf, ok := register.AbortFuncErrs[coinType]
-	if ok {
-		return mf(err)
-	}
-
-	return false
+	return register.ErrorKindOf(coinType, err).Abort()
 }
@@ -0,0 +1,209 @@
+package register
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/NpoolPlatform/message/npool/sphinxplugin"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/endpoints"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/env"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/types"
+)
+
+// ErrorKind the class of the handler error, it is reported to the proxy as the error code
+type ErrorKind string
+
+const (
+	// ErrorKindRetryable the transaction may succeed by retry, it is the default kind
+	ErrorKindRetryable ErrorKind = "retryable"
+	// ErrorKindPermanent the transaction will never succeed
+	ErrorKindPermanent ErrorKind = "permanent"
+	// ErrorKindInsufficientFunds the balance can not cover the amount or the fee
+	ErrorKindInsufficientFunds ErrorKind = "insufficient_funds"
+	// ErrorKindInvalidAddress the from or the to address is invalid
+	ErrorKindInvalidAddress ErrorKind = "invalid_address"
+	// ErrorKindNodeUnavailable the node can not be reached, the transaction is retried
+	ErrorKindNodeUnavailable ErrorKind = "node_unavailable"
+)
+
+// Abort the transaction with the error should be failed instead of retried
+func (kind ErrorKind) Abort() bool {
+	switch kind {
+	case ErrorKindPermanent, ErrorKindInsufficientFunds, ErrorKindInvalidAddress:
+		return true
+	}
+	return false
+}
+
+type kindError struct {
+	kind ErrorKind
+}
+
+func (e *kindError) Error() string {
+	return string(e.kind)
+}
+
+// the targets of errors.Is, errors.Is(err, ErrPermanent) is true for the permanent error
+var (
+	ErrRetryable         error = &kindError{kind: ErrorKindRetryable}
+	ErrPermanent         error = &kindError{kind: ErrorKindPermanent}
+	ErrInsufficientFunds error = &kindError{kind: ErrorKindInsufficientFunds}
+	ErrInvalidAddress    error = &kindError{kind: ErrorKindInvalidAddress}
+	ErrNodeUnavailable   error = &kindError{kind: ErrorKindNodeUnavailable}
+)
+
+// Error the classified error, the message of the wrapped error is kept
+type Error struct {
+	Kind ErrorKind
+	Err  error
+}
+
+// NewError classify the error as the kind
+func NewError(kind ErrorKind, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Kind: kind, Err: err}
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*kindError)
+	return ok && t.kind == e.Kind
+}
+
+// ErrorSentinel the kind of the sentinel error
+type ErrorSentinel struct {
+	Err  error
+	Kind ErrorKind
+}
+
+// ErrorClassifier classify the node error of the chain, empty kind means unknown
+type ErrorClassifier func(err error) ErrorKind
+
+// ErrorMessage the node message of the error kind
+type ErrorMessage struct {
+	Message string
+	Kind    ErrorKind
+}
+
+// MessageClassifier classify the error by the first node message it contains
+func MessageClassifier(messages ...ErrorMessage) ErrorClassifier {
+	return func(err error) ErrorKind {
+		if err == nil {
+			return ""
+		}
+		for _, v := range messages {
+			if strings.Contains(err.Error(), v.Message) {
+				return v.Kind
+			}
+		}
+		return ""
+	}
+}
+
+var (
+	// ErrErrorClassifierAlreadyRegister ..
+	ErrErrorClassifierAlreadyRegister = errors.New("error classifier already register")
+
+	// ErrorKinds the kinds of the sentinel errors, matched by errors.Is in order,
+	// the first matched one is used
+	ErrorKinds = []ErrorSentinel{
+		{Err: env.ErrEVNCoinNet, Kind: ErrorKindPermanent},
+		{Err: env.ErrEVNCoinNetValue, Kind: ErrorKindPermanent},
+		{Err: env.ErrSignTypeInvalid, Kind: ErrorKindPermanent},
+		{Err: env.ErrCIDInvalid, Kind: ErrorKindPermanent},
+		{Err: env.ErrContractInvalid, Kind: ErrorKindPermanent},
+		{Err: env.ErrTransactionFail, Kind: ErrorKindPermanent},
+
+		{Err: types.ErrAmountInvalid, Kind: ErrorKindPermanent},
+		{Err: types.ErrAmountPrecision, Kind: ErrorKindPermanent},
+
+		{Err: env.ErrAddressInvalid, Kind: ErrorKindInvalidAddress},
+
+		{Err: endpoints.ErrEndpointExhausted, Kind: ErrorKindNodeUnavailable},
+		{Err: endpoints.ErrEndpointsEmpty, Kind: ErrorKindNodeUnavailable},
+		{Err: context.DeadlineExceeded, Kind: ErrorKindNodeUnavailable},
+	}
+
+	ErrorClassifiers = make(map[sphinxplugin.CoinType]ErrorClassifier)
+)
+
+// RegisteErrorKind ..
+func RegisteErrorKind(kind ErrorKind, errs ...error) {
+	for _, err := range errs {
+		for _, v := range ErrorKinds {
+			if v.Err == err {
+				panic(fmt.Errorf("error %v kind already register", err))
+			}
+		}
+		ErrorKinds = append(ErrorKinds, ErrorSentinel{Err: err, Kind: kind})
+	}
+}
+
+// RegisteErrorClassifier ..
+func RegisteErrorClassifier(coinType sphinxplugin.CoinType, fn ErrorClassifier) error {
+	if _, ok := ErrorClassifiers[coinType]; ok {
+		return ErrErrorClassifierAlreadyRegister
+	}
+
+	ErrorClassifiers[coinType] = fn
+	return nil
+}
+
+// ErrorKindOf the kind of the error, the classified error is preferred, then the
+// sentinel errors and the node errors of the chain
+func ErrorKindOf(coinType sphinxplugin.CoinType, err error) ErrorKind {
+	if err == nil {
+		return ""
+	}
+
+	classified := &Error{}
+	if errors.As(err, &classified) {
+		return classified.Kind
+	}
+
+	for _, v := range ErrorKinds {
+		if errors.Is(err, v.Err) {
+			return v.Kind
+		}
+	}
+
+	if fn, ok := ErrorClassifiers[coinType]; ok {
+		if kind := fn(err); kind != "" {
+			return kind
+		}
+	}
+
+	return ErrorKindRetryable
+}
+
+// Classify wrap the error with its kind
+func Classify(coinType sphinxplugin.CoinType, err error) error {
+	if err == nil {
+		return nil
+	}
+	return NewError(ErrorKindOf(coinType, err), err)
+}
+
+// ErrorCode the machine readable code of the error
+func ErrorCode(coinType sphinxplugin.CoinType, err error) string {
+	return string(ErrorKindOf(coinType, err))
+}
+
+// ExitMessage the error message reported to the proxy, it is prefixed by the error code
+func ExitMessage(coinType sphinxplugin.CoinType, err error) string {
+	if err == nil {
+		return ""
+	}
+	return fmt.Sprintf("%v: %v", ErrorCode(coinType, err), err)
+}
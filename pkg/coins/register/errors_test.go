@@ -0,0 +1,88 @@
+package register
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/NpoolPlatform/message/npool/sphinxplugin"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/env"
+	"github.com/test-go/testify/assert"
+)
+
+// multiError the error matches all the errors
+type multiError struct {
+	errs []error
+}
+
+func (e *multiError) Error() string {
+	return fmt.Sprint(e.errs)
+}
+
+func (e *multiError) Is(target error) bool {
+	for _, err := range e.errs {
+		if err == target {
+			return true
+		}
+	}
+	return false
+}
+
+func TestErrorKindOf(t *testing.T) {
+	coinType := sphinxplugin.CoinType_CoinTypeUnKnow
+	assert.Nil(t, RegisteErrorClassifier(coinType, MessageClassifier(
+		ErrorMessage{Message: "insufficient funds", Kind: ErrorKindInsufficientFunds},
+		ErrorMessage{Message: "execution reverted", Kind: ErrorKindPermanent},
+	)))
+	defer delete(ErrorClassifiers, coinType)
+	assert.NotNil(t, RegisteErrorClassifier(coinType, nil))
+
+	// the sentinel is matched even it is wrapped
+	err := fmt.Errorf("pre sign: %w", env.ErrAddressInvalid)
+	assert.Equal(t, ErrorKindInvalidAddress, ErrorKindOf(coinType, err))
+	assert.Equal(t, ErrorKindNodeUnavailable, ErrorKindOf(coinType, fmt.Errorf("rpc: %w", context.DeadlineExceeded)))
+
+	// the node message
+	err = errors.New("execution reverted: insufficient funds for transfer")
+	assert.Equal(t, ErrorKindInsufficientFunds, ErrorKindOf(coinType, err))
+	assert.True(t, ErrorKindOf(coinType, err).Abort())
+	assert.Equal(t, ErrorKindRetryable, ErrorKindOf(sphinxplugin.CoinType_CoinTypebitcoin, err))
+
+	// unknown
+	err = errors.New("connection reset by peer")
+	assert.Equal(t, ErrorKindRetryable, ErrorKindOf(coinType, err))
+	assert.False(t, ErrorKindOf(coinType, err).Abort())
+	assert.Equal(t, ErrorKind(""), ErrorKindOf(coinType, nil))
+
+	// the insufficient balance is retried until the account is refilled
+	assert.False(t, ErrorKindOf(coinType, env.ErrInsufficientBalance).Abort())
+	assert.False(t, ErrorKindOf(coinType, env.ErrAmountInvalid).Abort())
+
+	// the first matched sentinel is used
+	err = &multiError{errs: []error{context.DeadlineExceeded, env.ErrAddressInvalid}}
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, ErrorKindInvalidAddress, ErrorKindOf(coinType, err))
+	}
+
+	// the classified error is preferred
+	err = fmt.Errorf("broadcast: %w", NewError(ErrorKindPermanent, errors.New("connection reset by peer")))
+	assert.Equal(t, ErrorKindPermanent, ErrorKindOf(coinType, err))
+}
+
+func TestClassify(t *testing.T) {
+	coinType := sphinxplugin.CoinType_CoinTypeUnKnow
+	assert.Nil(t, Classify(coinType, nil))
+
+	err := Classify(coinType, fmt.Errorf("pre sign: %w", env.ErrAddressInvalid))
+	assert.True(t, errors.Is(err, ErrInvalidAddress))
+	assert.True(t, errors.Is(err, env.ErrAddressInvalid))
+	assert.False(t, errors.Is(err, ErrPermanent))
+
+	classified := &Error{}
+	assert.True(t, errors.As(err, &classified))
+	assert.Equal(t, ErrorKindInvalidAddress, classified.Kind)
+
+	assert.Equal(t, "invalid_address: pre sign: address invalid", ExitMessage(coinType, err))
+	assert.Equal(t, "", ExitMessage(coinType, nil))
+}
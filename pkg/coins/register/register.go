@@ -7,7 +7,6 @@ import (
 
 	"github.com/NpoolPlatform/message/npool/sphinxplugin"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins"
-	"github.com/NpoolPlatform/sphinx-plugin/pkg/env"
 )
 
 // tokenInfo registe and tokenHandler registe --------------------
@@ -25,6 +24,9 @@ const (
 	OpWalletNew   OpType = 20
 	OpSign        OpType = 21
 	OpEstimateGas OpType = 30
+
+	// release the resource reserved by pre sign, eg: the evm nonce, when the pre sign payload is lost
+	OpPreSignRelease OpType = 4
 )
 
 var (
@@ -116,48 +118,6 @@ var (
 	ErrOpTypeNotFound   = errors.New("op type not found")
 )
 
-// error ----------------------------
-var (
-	// ErrAbortErrorAlreadyRegister ..
-	ErrAbortErrorAlreadyRegister = errors.New("abort error already register")
-
-	// ErrAbortErrorFuncAlreadyRegister ..
-	ErrAbortErrorFuncAlreadyRegister = errors.New("abort error func already register")
-
-	// TODO: think how to check not value error
-	AbortErrs = map[error]struct{}{
-		env.ErrEVNCoinNet:      {},
-		env.ErrEVNCoinNetValue: {},
-		env.ErrAddressInvalid:  {},
-		env.ErrSignTypeInvalid: {},
-		env.ErrCIDInvalid:      {},
-		env.ErrContractInvalid: {},
-		env.ErrTransactionFail: {},
-	}
-
-	AbortFuncErrs = make(map[sphinxplugin.CoinType]func(error) bool)
-)
-
-// RegisteAbortErr ..
-func RegisteAbortErr(errs ...error) {
-	for _, err := range errs {
-		if _, ok := AbortErrs[err]; ok {
-			panic(ErrAbortErrorAlreadyRegister)
-		}
-		AbortErrs[err] = struct{}{}
-	}
-}
-
-// RegisteAbortFuncErr ..
-func RegisteAbortFuncErr(coinType sphinxplugin.CoinType, f func(error) bool) error {
-	if _, ok := AbortFuncErrs[coinType]; ok {
-		return ErrAbortErrorFuncAlreadyRegister
-	}
-
-	AbortFuncErrs[coinType] = f
-	return nil
-}
-
 // env network
 var (
 	TokenNetHandlers               = make(map[sphinxplugin.CoinType]NetHandlerDef)
@@ -3,13 +3,11 @@ package sol
 import (
 	"errors"
 	"math/big"
-	"strings"
 
 	v1 "github.com/NpoolPlatform/message/npool/basetypes/v1"
 	"github.com/NpoolPlatform/message/npool/sphinxplugin"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/register"
-	solana "github.com/gagliardetto/solana-go"
 )
 
 var (
@@ -26,6 +24,11 @@ const (
 	ChainUnitExp        = 9
 	ChainNativeCoinName = "solana"
 	ChainID             = "101"
+
+	// BlockhashNotFound the blockhash of the transaction expired before broadcast
+	BlockhashNotFound = `Blockhash not found`
+	// AlreadyProcessed the transaction is on chain by the last broadcast
+	AlreadyProcessed = `This transaction has already been processed`
 )
 
 var (
@@ -33,6 +36,8 @@ var (
 	ErrSolBlockNotFound = errors.New("not found confirmed block in solana chain")
 	// ErrSolSignatureWrong ..
 	ErrSolSignatureWrong = errors.New("solana signature is wrong or failed")
+	// ErrBlockhashExpired the transaction is expired and not on chain
+	ErrBlockhashExpired = errors.New("solana transaction blockhash expired")
 )
 
 var (
@@ -41,9 +46,16 @@ var (
 	txFailed             = `Transaction simulation failed`
 	txSignatureWrong     = `Transaction signature verification failure`
 	txSignatureNotMatch  = `There is a mismatch in the length of the transaction signature`
-	txVersionWrong       = `Transaction version (0) is not supported by the requesting client`
-	stopErrMsg           = []string{lamportsLow, SolTransactionFailed, txFailed, txSignatureWrong, txSignatureNotMatch, txVersionWrong}
-	solanaToken          = &coins.TokenInfo{OfficialName: "Solana", Decimal: 9, Unit: "SOL", Name: ChainNativeCoinName, OfficialContract: ChainNativeCoinName, TokenType: coins.Solana}
+	errorMessages        = []register.ErrorMessage{
+		{Message: lamportsLow, Kind: register.ErrorKindInsufficientFunds},
+		{Message: tokenBalanceLow, Kind: register.ErrorKindInsufficientFunds},
+		{Message: lamportsLowSPL, Kind: register.ErrorKindInsufficientFunds},
+		{Message: SolTransactionFailed, Kind: register.ErrorKindPermanent},
+		{Message: txFailed, Kind: register.ErrorKindPermanent},
+		{Message: txSignatureWrong, Kind: register.ErrorKindPermanent},
+		{Message: txSignatureNotMatch, Kind: register.ErrorKindPermanent},
+	}
+	solanaToken = &coins.TokenInfo{OfficialName: "Solana", Decimal: 9, Unit: "SOL", Name: ChainNativeCoinName, OfficialContract: ChainNativeCoinName, TokenType: coins.Solana}
 )
 
 func init() {
@@ -64,31 +76,17 @@ func init() {
 	register.RegisteTokenInfo(solanaToken)
 }
 
-func ToSol(larm uint64) *big.Float {
-	// Convert lamports to sol:
-	return big.NewFloat(0).
-		Quo(
-			big.NewFloat(0).SetUint64(larm),
-			big.NewFloat(0).SetUint64(solana.LAMPORTS_PER_SOL),
-		)
-}
-
-func ToLarm(value float64) (uint64, big.Accuracy) {
-	return big.NewFloat(0).Mul(
-		big.NewFloat(0).SetFloat64(value),
-		big.NewFloat(0).SetUint64(solana.LAMPORTS_PER_SOL),
-	).Uint64()
+// ClassifyErr the kind of the node error
+func ClassifyErr(err error) register.ErrorKind {
+	return register.MessageClassifier(errorMessages...)(err)
 }
 
 func TxFailErr(err error) bool {
-	if err == nil {
-		return false
-	}
+	return ClassifyErr(err).Abort()
+}
 
-	for _, v := range stopErrMsg {
-		if strings.Contains(err.Error(), v) {
-			return true
-		}
-	}
-	return false
+// BlockhashExpired the finalized block height passed the last valid block height,
+// zero means the payload built by the old version which can not be checked
+func BlockhashExpired(lastValidBlockHeight, blockHeight uint64) bool {
+	return lastValidBlockHeight > 0 && blockHeight > lastValidBlockHeight
 }
@@ -0,0 +1,24 @@
+package sol
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/test-go/testify/assert"
+)
+
+func TestBlockhashExpired(t *testing.T) {
+	assert.False(t, BlockhashExpired(0, 100))
+	assert.False(t, BlockhashExpired(100, 99))
+	assert.False(t, BlockhashExpired(100, 100))
+	assert.True(t, BlockhashExpired(100, 101))
+
+	// it is a stop error, the broadcast must check the expired blockhash first
+	assert.True(t, TxFailErr(errors.New("Transaction simulation failed: "+BlockhashNotFound)))
+}
+
+func TestAlreadyProcessedErr(t *testing.T) {
+	assert.False(t, AlreadyProcessedErr(nil))
+	assert.True(t, AlreadyProcessedErr(errors.New("Transaction simulation failed: "+AlreadyProcessed)))
+	assert.False(t, AlreadyProcessedErr(errors.New("Transaction simulation failed: "+BlockhashNotFound)))
+}
@@ -0,0 +1,28 @@
+package sol
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// AlreadyProcessedErr the resent transaction is already on chain
+func AlreadyProcessedErr(err error) bool {
+	return err != nil && strings.Contains(err.Error(), AlreadyProcessed)
+}
+
+// Broadcasted the transaction of the signature is processed by the cluster, the
+// transaction which is on chain must not be rebuilt after its blockhash expired
+func Broadcasted(ctx context.Context, cli *rpc.Client, signature solana.Signature) (bool, error) {
+	statuses, err := cli.GetSignatureStatuses(ctx, true, signature)
+	if errors.Is(err, rpc.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return len(statuses.Value) > 0 && statuses.Value[0] != nil, nil
+}
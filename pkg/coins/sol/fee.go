@@ -0,0 +1,134 @@
+package sol
+
+import (
+	"context"
+	"encoding/binary"
+	"sort"
+
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/config"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+const (
+	// the compute units of the transfer with the compute budget instructions
+	SOLTransferComputeUnits = uint32(1_000)
+	SPLTransferComputeUnits = uint32(20_000)
+	// CreateATAComputeUnits the compute units of creating the associated token account
+	CreateATAComputeUnits = uint32(40_000)
+
+	// DefaultPriorityFeePercentile the percentile of the recent prioritization fees
+	DefaultPriorityFeePercentile = 75
+	// DefaultMaxComputeUnitPrice micro-lamports, the upper limit of the compute unit price
+	DefaultMaxComputeUnitPrice = uint64(1_000_000)
+
+	microLamportsPerLamport = 1_000_000
+
+	// the instruction index of the compute budget program
+	setComputeUnitLimit = byte(2)
+	setComputeUnitPrice = byte(3)
+)
+
+// ComputeBudgetProgramID the program of the compute unit limit and price
+var ComputeBudgetProgramID = solana.MustPublicKeyFromBase58("ComputeBudget111111111111111111111111111111")
+
+type prioritizationFee struct {
+	Slot              uint64 `json:"slot"`
+	PrioritizationFee uint64 `json:"prioritizationFee"`
+}
+
+// PriorityFeePolicy the percentile and the cap of the compute unit price
+type PriorityFeePolicy struct {
+	Percentile          int
+	MaxComputeUnitPrice uint64
+}
+
+func GetPriorityFeePolicy() *PriorityFeePolicy {
+	policy := &PriorityFeePolicy{
+		Percentile:          DefaultPriorityFeePercentile,
+		MaxComputeUnitPrice: DefaultMaxComputeUnitPrice,
+	}
+
+	envInfo := config.GetENV()
+	if envInfo == nil {
+		return policy
+	}
+	if envInfo.SolPriorityFeePercentile > 0 && envInfo.SolPriorityFeePercentile <= 100 {
+		policy.Percentile = envInfo.SolPriorityFeePercentile
+	}
+	if envInfo.SolMaxComputeUnitPrice > 0 {
+		policy.MaxComputeUnitPrice = envInfo.SolMaxComputeUnitPrice
+	}
+	return policy
+}
+
+// GetRecentPrioritizationFees the compute unit prices(micro-lamports) paid by the
+// recent transactions which lock the accounts
+func GetRecentPrioritizationFees(ctx context.Context, cli *rpc.Client, accounts []solana.PublicKey) ([]uint64, error) {
+	addresses := []string{}
+	for _, v := range accounts {
+		addresses = append(addresses, v.String())
+	}
+
+	out := []prioritizationFee{}
+	err := cli.RPCCallForInto(ctx, &out, "getRecentPrioritizationFees", []interface{}{addresses})
+	if err != nil {
+		return nil, err
+	}
+
+	fees := []uint64{}
+	for _, v := range out {
+		fees = append(fees, v.PrioritizationFee)
+	}
+	return fees, nil
+}
+
+// ComputeUnitPrice pick the percentile of the fees, it is limited by the cap
+func (policy *PriorityFeePolicy) ComputeUnitPrice(fees []uint64) uint64 {
+	if len(fees) == 0 {
+		return 0
+	}
+
+	sorted := append([]uint64{}, fees...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	idx := (len(sorted)*policy.Percentile + 99) / 100
+	if idx > 0 {
+		idx--
+	}
+	if sorted[idx] > policy.MaxComputeUnitPrice {
+		return policy.MaxComputeUnitPrice
+	}
+	return sorted[idx]
+}
+
+// EstimateComputeUnitPrice the compute unit price of the transaction which writes the accounts
+func EstimateComputeUnitPrice(ctx context.Context, cli *rpc.Client, accounts []solana.PublicKey) (uint64, error) {
+	fees, err := GetRecentPrioritizationFees(ctx, cli, accounts)
+	if err != nil {
+		return 0, err
+	}
+	return GetPriorityFeePolicy().ComputeUnitPrice(fees), nil
+}
+
+// ComputeBudgetInstructions set the compute unit limit and price of the transaction
+func ComputeBudgetInstructions(limit uint32, price uint64) []solana.Instruction {
+	limitData := make([]byte, 5)
+	limitData[0] = setComputeUnitLimit
+	binary.LittleEndian.PutUint32(limitData[1:], limit)
+
+	priceData := make([]byte, 9)
+	priceData[0] = setComputeUnitPrice
+	binary.LittleEndian.PutUint64(priceData[1:], price)
+
+	return []solana.Instruction{
+		solana.NewInstruction(ComputeBudgetProgramID, solana.AccountMetaSlice{}, limitData),
+		solana.NewInstruction(ComputeBudgetProgramID, solana.AccountMetaSlice{}, priceData),
+	}
+}
+
+// Fee the lamports of the signature and the priority fee
+func Fee(limit uint32, price uint64) uint64 {
+	priorityFee := (uint64(limit)*price + microLamportsPerLamport - 1) / microLamportsPerLamport
+	return LamportsPerSignature + priorityFee
+}
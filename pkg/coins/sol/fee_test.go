@@ -0,0 +1,43 @@
+package sol
+
+import (
+	"testing"
+
+	"github.com/test-go/testify/assert"
+)
+
+func TestComputeUnitPrice(t *testing.T) {
+	policy := &PriorityFeePolicy{
+		Percentile:          75,
+		MaxComputeUnitPrice: 5_000,
+	}
+	assert.Equal(t, uint64(0), policy.ComputeUnitPrice(nil))
+
+	fees := []uint64{400, 100, 300, 200}
+	assert.Equal(t, uint64(300), policy.ComputeUnitPrice(fees))
+	// the fees are not sorted in place
+	assert.Equal(t, []uint64{400, 100, 300, 200}, fees)
+
+	policy.Percentile = 100
+	assert.Equal(t, uint64(400), policy.ComputeUnitPrice(fees))
+
+	policy.MaxComputeUnitPrice = 250
+	assert.Equal(t, uint64(250), policy.ComputeUnitPrice(fees))
+}
+
+func TestComputeBudgetInstructions(t *testing.T) {
+	instructions := ComputeBudgetInstructions(SOLTransferComputeUnits, 1_500)
+	assert.Equal(t, 2, len(instructions))
+
+	data, err := instructions[0].Data()
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{2, 0xe8, 0x03, 0, 0}, data)
+
+	data, err = instructions[1].Data()
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{3, 0xdc, 0x05, 0, 0, 0, 0, 0, 0}, data)
+	assert.True(t, instructions[1].ProgramID().Equals(ComputeBudgetProgramID))
+
+	// 1000 units * 1500 micro-lamports = 1.5 lamports
+	assert.Equal(t, LamportsPerSignature+2, Fee(SOLTransferComputeUnits, 1_500))
+}
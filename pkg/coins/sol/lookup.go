@@ -0,0 +1,137 @@
+package sol
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/config"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+const (
+	// the meta of the lookup table account: type u32, deactivation slot u64, last
+	// extended slot u64, start index u8, authority option<pubkey>, padding u16
+	lookupTableMetaSize = 56
+	lookupTableType     = uint32(1)
+	// MaxSupportedTransactionVersion the highest transaction version the client accepts
+	MaxSupportedTransactionVersion = 0
+)
+
+var (
+	// AddressLookupTableProgramID the owner of the lookup table accounts
+	AddressLookupTableProgramID = solana.MustPublicKeyFromBase58("AddressLookupTab1e1111111111111111111111111")
+
+	ErrLookupTableInvalid = errors.New("solana address lookup table is invalid")
+)
+
+// TxVersion the version of the built transaction
+func TxVersion() string {
+	if envInfo := config.GetENV(); envInfo != nil && envInfo.SolTxVersion == TxVersion0 {
+		return TxVersion0
+	}
+	return TxVersionLegacy
+}
+
+// LookupTableAddresses the lookup tables of the v0 transaction
+func LookupTableAddresses() ([]solana.PublicKey, error) {
+	envInfo := config.GetENV()
+	if envInfo == nil || envInfo.SolLookupTables == "" {
+		return nil, nil
+	}
+
+	addresses := []solana.PublicKey{}
+	for _, v := range strings.Split(envInfo.SolLookupTables, ",") {
+		if v = strings.TrimSpace(v); v == "" {
+			continue
+		}
+		address, err := solana.PublicKeyFromBase58(v)
+		if err != nil {
+			return nil, fmt.Errorf("%v, %v, %v", ErrLookupTableInvalid, v, err)
+		}
+		addresses = append(addresses, address)
+	}
+	return addresses, nil
+}
+
+// ParseLookupTable decode the addresses of the lookup table account, the
+// deactivated table can not be used
+func ParseLookupTable(address solana.PublicKey, data []byte) (*LookupTable, error) {
+	if len(data) < lookupTableMetaSize || (len(data)-lookupTableMetaSize)%solana.PublicKeyLength != 0 {
+		return nil, fmt.Errorf("%v, %v data size %v", ErrLookupTableInvalid, address, len(data))
+	}
+	if binary.LittleEndian.Uint32(data[0:4]) != lookupTableType {
+		return nil, fmt.Errorf("%v, %v is not initialized", ErrLookupTableInvalid, address)
+	}
+	if binary.LittleEndian.Uint64(data[4:12]) != math.MaxUint64 {
+		return nil, fmt.Errorf("%v, %v is deactivated", ErrLookupTableInvalid, address)
+	}
+
+	table := &LookupTable{Address: address.String()}
+	for i := lookupTableMetaSize; i < len(data); i += solana.PublicKeyLength {
+		table.Addresses = append(table.Addresses, solana.PublicKeyFromBytes(data[i:i+solana.PublicKeyLength]).String())
+	}
+	return table, nil
+}
+
+// GetLookupTables resolve the addresses of the lookup tables
+func GetLookupTables(ctx context.Context, cli *rpc.Client, addresses []solana.PublicKey) ([]*LookupTable, error) {
+	tables := []*LookupTable{}
+	for _, address := range addresses {
+		account, err := cli.GetAccountInfoWithOpts(ctx, address, &rpc.GetAccountInfoOpts{
+			Commitment: rpc.CommitmentFinalized,
+			Encoding:   solana.EncodingBase64,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if account.Value == nil || !account.Value.Owner.Equals(AddressLookupTableProgramID) {
+			return nil, fmt.Errorf("%v, %v is not the lookup table", ErrLookupTableInvalid, address)
+		}
+
+		table, err := ParseLookupTable(address, account.Value.Data.GetBinary())
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+// GetTransaction query the legacy and the versioned transaction, the node reject
+// the versioned one without the max supported transaction version
+func GetTransaction(ctx context.Context, cli *rpc.Client, signature solana.Signature) (*rpc.GetTransactionResult, error) {
+	var out *rpc.GetTransactionResult
+	err := cli.RPCCallForInto(ctx, &out, "getTransaction", []interface{}{
+		signature,
+		rpc.M{
+			"encoding":                       solana.EncodingBase64,
+			"commitment":                     rpc.CommitmentFinalized,
+			"maxSupportedTransactionVersion": MaxSupportedTransactionVersion,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if out == nil {
+		return nil, rpc.ErrNotFound
+	}
+	return out, nil
+}
+
+// GetTxLookupTables the lookup tables of the built transaction, the legacy one has none
+func GetTxLookupTables(ctx context.Context, cli *rpc.Client) ([]*LookupTable, error) {
+	if TxVersion() != TxVersion0 {
+		return nil, nil
+	}
+
+	addresses, err := LookupTableAddresses()
+	if err != nil {
+		return nil, err
+	}
+	return GetLookupTables(ctx, cli, addresses)
+}
@@ -3,18 +3,19 @@ package plugin
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
+	"strings"
 
+	"github.com/NpoolPlatform/go-service-framework/pkg/logger"
 	"github.com/NpoolPlatform/message/npool/sphinxplugin"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/register"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/sol"
-	"github.com/NpoolPlatform/sphinx-plugin/pkg/log"
 	ct "github.com/NpoolPlatform/sphinx-plugin/pkg/types"
 
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/env"
-	bin "github.com/gagliardetto/binary"
 	solana "github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
 )
@@ -34,20 +35,20 @@ func init() {
 	register.RegisteTokenHandler(
 		coins.Solana,
 		register.OpBroadcast,
-		broadcast,
+		Broadcast,
 	)
 	register.RegisteTokenHandler(
 		coins.Solana,
 		register.OpSyncTx,
-		syncTx,
+		SyncTx,
 	)
 
-	err := register.RegisteAbortFuncErr(sphinxplugin.CoinType_CoinTypesolana, sol.TxFailErr)
+	err := register.RegisteErrorClassifier(sphinxplugin.CoinType_CoinTypesolana, sol.ClassifyErr)
 	if err != nil {
 		panic(err)
 	}
 
-	err = register.RegisteAbortFuncErr(sphinxplugin.CoinType_CoinTypetsolana, sol.TxFailErr)
+	err = register.RegisteErrorClassifier(sphinxplugin.CoinType_CoinTypetsolana, sol.ClassifyErr)
 	if err != nil {
 		panic(err)
 	}
@@ -89,16 +90,7 @@ func walletBalance(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (
 		return in, err
 	}
 
-	balance := sol.ToSol(bl.Value)
-	f, exact := balance.Float64()
-	if exact != big.Exact {
-		log.Warnf("wallet balance transfer warning balance from->to %v-%v", balance.String(), f)
-	}
-
-	_out := ct.WalletBalanceResponse{
-		Balance:    f,
-		BalanceStr: balance.String(),
-	}
+	_out := ct.NewWalletBalanceResponse(ct.NewAmountFromAtomic(new(big.Int).SetUint64(bl.Value), tokenInfo.Decimal))
 
 	return json.Marshal(_out)
 }
@@ -113,10 +105,40 @@ func preSign(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []
 		return nil, env.ErrEVNCoinNetValue
 	}
 
+	from, err := solana.PublicKeyFromBase58(info.From)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := solana.PublicKeyFromBase58(info.To)
+	if err != nil {
+		return nil, err
+	}
+
+	amount, err := info.GetAmount(tokenInfo.Decimal)
+	if err != nil {
+		return nil, err
+	}
+	info.Amount = amount.String()
+
 	client := sol.Client()
 
-	var recentBlockHash *rpc.GetLatestBlockhashResult
+	var (
+		recentBlockHash  *rpc.GetLatestBlockhashResult
+		computeUnitPrice uint64
+		lookupTables     []*sol.LookupTable
+	)
 	err = client.WithClient(ctx, func(_ctx context.Context, cli *rpc.Client) (bool, error) {
+		computeUnitPrice, err = sol.EstimateComputeUnitPrice(_ctx, cli, []solana.PublicKey{from, to})
+		if err != nil {
+			return true, err
+		}
+
+		lookupTables, err = sol.GetTxLookupTables(_ctx, cli)
+		if err != nil {
+			return true, err
+		}
+
 		recentBlockHash, err = cli.GetLatestBlockhash(_ctx, rpc.CommitmentFinalized)
 		if err != nil || recentBlockHash == nil {
 			return true, err
@@ -128,55 +150,99 @@ func preSign(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []
 	}
 
 	_out := sol.SignMsgTx{
-		BaseInfo:        info,
-		RecentBlockHash: recentBlockHash.Value.Blockhash.String(),
+		BaseInfo:             info,
+		RecentBlockHash:      recentBlockHash.Value.Blockhash.String(),
+		ComputeUnitLimit:     sol.SOLTransferComputeUnits,
+		ComputeUnitPrice:     computeUnitPrice,
+		LastValidBlockHeight: recentBlockHash.Value.LastValidBlockHeight,
+		Version:              sol.TxVersion(),
+		LookupTables:         lookupTables,
 	}
 
 	return json.Marshal(_out)
 }
 
-func broadcast(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []byte, err error) {
+func Broadcast(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []byte, err error) {
 	info := sol.BroadcastRequest{}
 	if err := json.Unmarshal(in, &info); err != nil {
 		return in, err
 	}
 
-	tx, err := solana.TransactionFromDecoder(bin.NewBinDecoder(info.Signature))
+	// the legacy and the v0 transaction
+	tx, err := sol.DecodeRawTransaction(info.Signature)
 	if err != nil {
 		return in, err
 	}
 
 	err = tx.VerifySignatures()
-	if err != nil {
+	if err != nil || len(tx.Signatures) == 0 {
 		return in, sol.ErrSolSignatureWrong
 	}
 
+	// the first signature is the tx id, it is known before broadcast
+	cid := tx.Signatures[0]
 	client := sol.Client()
-	if err != nil {
-		return in, err
-	}
-	var cid solana.Signature
 	err = client.WithClient(ctx, func(_ctx context.Context, cli *rpc.Client) (bool, error) {
-		cid, err = cli.SendTransaction(_ctx, tx)
+		_, err = cli.SendRawTransaction(_ctx, info.Signature)
+		// the transaction is on chain by the last broadcast
+		if sol.AlreadyProcessedErr(err) {
+			return false, nil
+		}
+		// the blockhash may be expired after the last broadcast is on chain
+		if err != nil && strings.Contains(err.Error(), sol.BlockhashNotFound) {
+			broadcasted, _err := sol.Broadcasted(_ctx, cli, cid)
+			if _err != nil {
+				return true, _err
+			}
+			if broadcasted {
+				return false, nil
+			}
+			return false, err
+		}
 		if err != nil && !sol.TxFailErr(err) {
 			return true, err
 		}
 		return false, err
 	})
+	// the blockhash expired before broadcast, the transaction never be on chain
+	if err != nil && strings.Contains(err.Error(), sol.BlockhashNotFound) && info.BaseInfo != nil {
+		resign, err := rebuild(ctx, info.BaseInfo, tokenInfo)
+		if err != nil {
+			return in, err
+		}
+		return json.Marshal(&ct.BroadcastInfo{Resign: resign})
+	}
 	if err != nil {
 		return in, err
 	}
 
-	_out := ct.SyncRequest{
-		TxID: cid.String(),
+	_out := sol.SyncRequest{
+		TxID:                 cid.String(),
+		LastValidBlockHeight: info.LastValidBlockHeight,
+		BaseInfo:             info.BaseInfo,
 	}
 
 	return json.Marshal(_out)
 }
 
-// syncTx sync transaction status on chain
-func syncTx(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []byte, err error) {
-	info := ct.SyncRequest{}
+// rebuild build the expired transaction by the pre sign handler of the token,
+// the payload is routed back to sign
+func rebuild(ctx context.Context, baseInfo *ct.BaseInfo, tokenInfo *coins.TokenInfo) ([]byte, error) {
+	handler, ok := register.TokenHandlers[tokenInfo.TokenType][register.OpPreSign]
+	if !ok {
+		return nil, env.ErrTransactionFail
+	}
+
+	in, err := json.Marshal(baseInfo)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, in, tokenInfo)
+}
+
+// SyncTx sync transaction status on chain
+func SyncTx(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []byte, err error) {
+	info := sol.SyncRequest{}
 	if err := json.Unmarshal(in, &info); err != nil {
 		return in, err
 	}
@@ -187,15 +253,22 @@ func syncTx(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []b
 	}
 
 	client := sol.Client()
-	var chainMsg *rpc.GetTransactionResult
+	var (
+		chainMsg    *rpc.GetTransactionResult
+		blockHeight uint64
+	)
 	err = client.WithClient(ctx, func(_ctx context.Context, cli *rpc.Client) (bool, error) {
-		chainMsg, err = cli.GetTransaction(
-			_ctx,
-			signature,
-			&rpc.GetTransactionOpts{
-				Encoding:   solana.EncodingBase58,
-				Commitment: rpc.CommitmentFinalized,
-			})
+		// query the height before the transaction, the transaction not found
+		// after the height passed is never on chain
+		blockHeight, err = cli.GetBlockHeight(_ctx, rpc.CommitmentFinalized)
+		if err != nil {
+			return true, err
+		}
+
+		chainMsg, err = sol.GetTransaction(_ctx, cli, signature)
+		if errors.Is(err, rpc.ErrNotFound) {
+			return false, nil
+		}
 		if err != nil {
 			return true, err
 		}
@@ -206,6 +279,25 @@ func syncTx(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []b
 		return in, err
 	}
 
+	if chainMsg == nil && sol.BlockhashExpired(info.LastValidBlockHeight, blockHeight) {
+		if info.BaseInfo == nil {
+			return in, fmt.Errorf("%v, %v", sol.SolTransactionFailed, sol.ErrBlockhashExpired)
+		}
+
+		logger.Sugar().Warnw(
+			"SyncTx",
+			"TxID", info.TxID,
+			"LastValidBlockHeight", info.LastValidBlockHeight,
+			"BlockHeight", blockHeight,
+			"Error", sol.ErrBlockhashExpired,
+		)
+		resign, err := rebuild(ctx, info.BaseInfo, tokenInfo)
+		if err != nil {
+			return in, err
+		}
+		return json.Marshal(&ct.SyncResponse{Resign: resign})
+	}
+
 	if chainMsg == nil {
 		return in, env.ErrWaitMessageOnChain
 	}
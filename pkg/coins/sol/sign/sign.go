@@ -4,14 +4,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"math/big"
 
 	"github.com/NpoolPlatform/go-service-framework/pkg/oss"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/register"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/sol"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/env"
-	"github.com/NpoolPlatform/sphinx-plugin/pkg/log"
 	ct "github.com/NpoolPlatform/sphinx-plugin/pkg/types"
 	bin "github.com/gagliardetto/binary"
 	"github.com/gagliardetto/solana-go"
@@ -22,7 +20,7 @@ func init() {
 	register.RegisteTokenHandler(
 		coins.Solana,
 		register.OpWalletNew,
-		createAccount,
+		CreateAccount,
 	)
 	register.RegisteTokenHandler(
 		coins.Solana,
@@ -31,10 +29,11 @@ func init() {
 	)
 }
 
-const s3KeyPrxfix = "solana/"
+// S3KeyPrxfix the key store of the solana account, the spl tokens share it
+const S3KeyPrxfix = "solana/"
 
-// createAccount ..
-func createAccount(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []byte, err error) {
+// CreateAccount ..
+func CreateAccount(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []byte, err error) {
 	info := ct.NewAccountRequest{}
 	if err := json.Unmarshal(in, &info); err != nil {
 		return nil, err
@@ -56,7 +55,7 @@ func createAccount(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (
 		return nil, err
 	}
 
-	err = oss.PutObject(ctx, s3KeyPrxfix+addr, account.PrivateKey, true)
+	err = oss.PutObject(ctx, S3KeyPrxfix+addr, account.PrivateKey, true)
 	if err != nil {
 		return nil, err
 	}
@@ -72,10 +71,8 @@ func signTx(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []b
 	}
 
 	var (
-		from   = info.BaseInfo.From
-		to     = info.BaseInfo.To
-		value  = info.BaseInfo.Value
-		rbHash = info.RecentBlockHash
+		from = info.BaseInfo.From
+		to   = info.BaseInfo.To
 	)
 
 	fPublicKey, err := solana.PublicKeyFromBase58(from)
@@ -88,18 +85,19 @@ func signTx(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []b
 		return nil, err
 	}
 
-	rhash, err := solana.HashFromBase58(rbHash)
+	amount, err := info.BaseInfo.GetAmount(tokenInfo.Decimal)
 	if err != nil {
 		return nil, err
 	}
-
-	lamports, accuracy := sol.ToLarm(value)
-	if accuracy != big.Exact {
-		log.Warnf("transafer sol amount not accuracy: from %v-> to %v", value, lamports)
+	if !amount.BigInt().IsUint64() {
+		return nil, ct.ErrAmountInvalid
 	}
+	lamports := amount.BigInt().Uint64()
 
-	// build tx
-	tx, err := solana.NewTransaction(
+	return SignInstructions(
+		ctx,
+		S3KeyPrxfix,
+		&info,
 		[]solana.Instruction{
 			system.NewTransferInstruction(
 				lamports,
@@ -107,31 +105,78 @@ func signTx(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []b
 				tPublicKey,
 			).Build(),
 		},
-		rhash,
-		solana.TransactionPayer(fPublicKey),
 	)
+}
+
+// SignInstructions build the transaction paid by the sender and sign it
+func SignInstructions(ctx context.Context, s3Store string, info *sol.SignMsgTx, instructions []solana.Instruction) (out []byte, err error) {
+	from := info.BaseInfo.From
+	fPublicKey, err := solana.PublicKeyFromBase58(from)
+	if err != nil {
+		return nil, err
+	}
+
+	rhash, err := solana.HashFromBase58(info.RecentBlockHash)
 	if err != nil {
 		return nil, err
 	}
 
-	pk, err := oss.GetObject(ctx, s3KeyPrxfix+from, true)
+	// the payload built by the old version has no compute budget
+	if info.ComputeUnitLimit > 0 {
+		instructions = append(
+			sol.ComputeBudgetInstructions(info.ComputeUnitLimit, info.ComputeUnitPrice),
+			instructions...,
+		)
+	}
+
+	pk, err := oss.GetObject(ctx, s3Store+from, true)
 	if err != nil {
 		return nil, err
 	}
 
 	accountFrom := solana.PrivateKey(pk)
-	_, err = tx.Sign(
-		func(key solana.PublicKey) *solana.PrivateKey {
-			if accountFrom.PublicKey().Equals(key) {
-				return &accountFrom
-			}
-			return nil
-		},
+	getter := func(key solana.PublicKey) *solana.PrivateKey {
+		if accountFrom.PublicKey().Equals(key) {
+			return &accountFrom
+		}
+		return nil
+	}
+
+	var raw []byte
+	if info.Version == sol.TxVersion0 {
+		raw, err = signV0(instructions, fPublicKey, rhash, info.LookupTables, getter)
+	} else {
+		raw, err = signLegacy(instructions, fPublicKey, rhash, getter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	_out := sol.BroadcastRequest{
+		Signature:            raw,
+		LastValidBlockHeight: info.LastValidBlockHeight,
+		BaseInfo:             &info.BaseInfo,
+	}
+
+	return json.Marshal(_out)
+}
+
+func signLegacy(instructions []solana.Instruction, payer solana.PublicKey, rhash solana.Hash, getter func(key solana.PublicKey) *solana.PrivateKey) ([]byte, error) {
+	// build tx
+	tx, err := solana.NewTransaction(
+		instructions,
+		rhash,
+		solana.TransactionPayer(payer),
 	)
 	if err != nil {
 		return nil, err
 	}
 
+	_, err = tx.Sign(getter)
+	if err != nil {
+		return nil, err
+	}
+
 	err = tx.VerifySignatures()
 	if err != nil {
 		return nil, err
@@ -141,10 +186,27 @@ func signTx(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []b
 	if err := tx.MarshalWithEncoder(bin.NewBinEncoder(&buf)); err != nil {
 		return nil, err
 	}
+	return buf.Bytes(), nil
+}
 
-	_out := sol.BroadcastRequest{
-		Signature: buf.Bytes(),
+// signV0 the accounts which are not signer or program are loaded from the lookup tables
+func signV0(instructions []solana.Instruction, payer solana.PublicKey, rhash solana.Hash, tables []*sol.LookupTable, getter func(key solana.PublicKey) *solana.PrivateKey) ([]byte, error) {
+	message, err := sol.NewMessageV0(instructions, payer, rhash, tables)
+	if err != nil {
+		return nil, err
 	}
 
-	return json.Marshal(_out)
+	raw, err := sol.SignMessageV0(message, getter)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := sol.DecodeRawTransaction(raw)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.VerifySignatures(); err != nil {
+		return nil, err
+	}
+	return raw, nil
 }
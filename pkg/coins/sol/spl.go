@@ -0,0 +1,139 @@
+package sol
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/env"
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+const (
+	// TokenAccountSize the data size of the spl token account, the rent of it
+	// is paid when the associated token account created
+	TokenAccountSize = 165
+	// LamportsPerSignature the base fee of the transaction signature
+	LamportsPerSignature = uint64(5000)
+
+	tokenBalanceLow = `spl token balance is not sufficient`
+	lamportsLowSPL  = `sol balance is not sufficient for the spl transfer`
+)
+
+var ErrTokenAccountInvalid = errors.New("spl token account is invalid")
+
+// TokenAccount the spl token account of the owner
+type TokenAccount struct {
+	Address solana.PublicKey
+	Amount  uint64
+}
+
+// SPLMint the mint of the spl token, the test token which has no mint use the ENV_CONTRACT
+func SPLMint(tokenInfo *coins.TokenInfo) (solana.PublicKey, error) {
+	contract := tokenInfo.Contract
+	if contract == "" && tokenInfo.Net == coins.CoinNetTest {
+		contract, _ = env.LookupEnv(env.ENVCONTRACT)
+	}
+	if contract == "" {
+		return solana.PublicKey{}, env.ErrContractInvalid
+	}
+
+	mint, err := solana.PublicKeyFromBase58(contract)
+	if err != nil {
+		return solana.PublicKey{}, fmt.Errorf("mint %v, %v, %v", contract, env.ErrContractInvalid, err)
+	}
+	return mint, nil
+}
+
+// GetTokenAccounts the token accounts of the owner by the mint, the associated
+// token account is one of them
+func GetTokenAccounts(ctx context.Context, cli *rpc.Client, owner, mint solana.PublicKey) ([]*TokenAccount, error) {
+	result, err := cli.GetTokenAccountsByOwner(
+		ctx,
+		owner,
+		&rpc.GetTokenAccountsConfig{Mint: mint.ToPointer()},
+		&rpc.GetTokenAccountsOpts{
+			Commitment: rpc.CommitmentFinalized,
+			Encoding:   solana.EncodingBase64,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := []*TokenAccount{}
+	for _, v := range result.Value {
+		if v == nil || v.Account.Data == nil {
+			return nil, ErrTokenAccountInvalid
+		}
+
+		account := token.Account{}
+		if err := account.UnmarshalWithDecoder(bin.NewBinDecoder(v.Account.Data.GetBinary())); err != nil {
+			return nil, fmt.Errorf("%v, %v", ErrTokenAccountInvalid, err)
+		}
+		if !account.Mint.Equals(mint) || !account.Owner.Equals(owner) {
+			return nil, ErrTokenAccountInvalid
+		}
+
+		accounts = append(accounts, &TokenAccount{
+			Address: v.Pubkey,
+			Amount:  account.Amount,
+		})
+	}
+	return accounts, nil
+}
+
+// SourceTokenAccount pick the token account which covers the amount, the
+// associated token account is preferred
+func SourceTokenAccount(accounts []*TokenAccount, owner, mint solana.PublicKey, amount uint64) (solana.PublicKey, error) {
+	ata, _, err := solana.FindAssociatedTokenAddress(owner, mint)
+	if err != nil {
+		return solana.PublicKey{}, err
+	}
+
+	var (
+		source solana.PublicKey
+		found  bool
+		total  uint64
+	)
+	for _, v := range accounts {
+		total += v.Amount
+		if v.Amount < amount {
+			continue
+		}
+		if !found || v.Address.Equals(ata) {
+			source = v.Address
+			found = true
+		}
+	}
+	if !found {
+		return solana.PublicKey{}, fmt.Errorf("%v, need %v but %v in %v accounts", tokenBalanceLow, amount, total, len(accounts))
+	}
+	return source, nil
+}
+
+// CheckSPLLamports the sol of the sender must cover the fee and the rent of
+// the recipient associated token account
+func CheckSPLLamports(ctx context.Context, cli *rpc.Client, owner solana.PublicKey, createDestination bool, fee uint64) error {
+	need := fee
+	if createDestination {
+		rent, err := cli.GetMinimumBalanceForRentExemption(ctx, TokenAccountSize, rpc.CommitmentFinalized)
+		if err != nil {
+			return err
+		}
+		need += rent
+	}
+
+	balance, err := cli.GetBalance(ctx, owner, rpc.CommitmentFinalized)
+	if err != nil {
+		return err
+	}
+	if balance == nil || balance.Value < need {
+		return fmt.Errorf("%v, need %v lamports", lamportsLowSPL, need)
+	}
+	return nil
+}
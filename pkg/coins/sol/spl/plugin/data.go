@@ -0,0 +1,37 @@
+package plugin
+
+import (
+	v1 "github.com/NpoolPlatform/message/npool/basetypes/v1"
+	"github.com/NpoolPlatform/message/npool/sphinxplugin"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/register"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/sol"
+)
+
+func init() {
+	for i := range splTokens {
+		// set chain info
+		splTokens[i].ChainType = sol.ChainType
+		splTokens[i].ChainNativeUnit = sol.ChainNativeUnit
+		splTokens[i].ChainAtomicUnit = sol.ChainAtomicUnit
+		splTokens[i].ChainUnitExp = sol.ChainUnitExp
+		splTokens[i].GasType = v1.GasType_GasUnsupported
+		splTokens[i].ChainID = sol.ChainID
+		splTokens[i].ChainNickname = sol.ChainType.String()
+		splTokens[i].ChainNativeCoinName = sol.ChainNativeCoinName
+
+		splTokens[i].TokenType = coins.SPL
+		splTokens[i].Net = coins.CoinNetMain
+		splTokens[i].Waight = 1
+		splTokens[i].Contract = splTokens[i].OfficialContract
+		splTokens[i].CoinType = sphinxplugin.CoinType_CoinTypesolana
+		splTokens[i].Name = coins.GenerateName(&splTokens[i])
+		register.RegisteTokenInfo(&splTokens[i])
+	}
+}
+
+// the contract is the mint of the token
+var splTokens = []coins.TokenInfo{
+	{OfficialName: "USD Coin", Decimal: 6, Unit: "USDC", OfficialContract: "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"},
+	{OfficialName: "Tether USD", Decimal: 6, Unit: "USDT", OfficialContract: "Es9vMFrzaCERmJfrF4H2FYD4KCoNkY11McCe8BenwNYB"},
+}
@@ -0,0 +1,204 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/register"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/sol"
+	sol_plugin "github.com/NpoolPlatform/sphinx-plugin/pkg/coins/sol/plugin"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/env"
+	ct "github.com/NpoolPlatform/sphinx-plugin/pkg/types"
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// here register plugin func
+func init() {
+	register.RegisteTokenHandler(
+		coins.SPL,
+		register.OpGetBalance,
+		walletBalance,
+	)
+	register.RegisteTokenHandler(
+		coins.SPL,
+		register.OpPreSign,
+		preSign,
+	)
+	register.RegisteTokenHandler(
+		coins.SPL,
+		register.OpBroadcast,
+		sol_plugin.Broadcast,
+	)
+	register.RegisteTokenHandler(
+		coins.SPL,
+		register.OpSyncTx,
+		sol_plugin.SyncTx,
+	)
+}
+
+// walletBalance the sum of the token accounts of the owner
+func walletBalance(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []byte, err error) {
+	info := ct.WalletBalanceRequest{}
+	if err := json.Unmarshal(in, &info); err != nil {
+		return in, err
+	}
+
+	if !coins.CheckSupportNet(tokenInfo.Net) {
+		return in, env.ErrEVNCoinNetValue
+	}
+
+	if info.Address == "" {
+		return in, env.ErrAddressInvalid
+	}
+
+	owner, err := solana.PublicKeyFromBase58(info.Address)
+	if err != nil {
+		return in, err
+	}
+
+	mint, err := sol.SPLMint(tokenInfo)
+	if err != nil {
+		return in, err
+	}
+
+	client := sol.Client()
+	var accounts []*sol.TokenAccount
+	err = client.WithClient(ctx, func(_ctx context.Context, cli *rpc.Client) (bool, error) {
+		accounts, err = sol.GetTokenAccounts(_ctx, cli, owner, mint)
+		if err != nil {
+			return true, err
+		}
+		return false, err
+	})
+	if err != nil {
+		return in, err
+	}
+
+	balance := big.NewInt(0)
+	for _, v := range accounts {
+		balance.Add(balance, new(big.Int).SetUint64(v.Amount))
+	}
+
+	_out := ct.NewWalletBalanceResponse(ct.NewAmountFromAtomic(balance, tokenInfo.Decimal))
+
+	return json.Marshal(_out)
+}
+
+// preSign pick the source token account and check the recipient associated token account
+func preSign(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []byte, err error) {
+	info := ct.BaseInfo{}
+	if err := json.Unmarshal(in, &info); err != nil {
+		return in, err
+	}
+
+	if !coins.CheckSupportNet(info.ENV) {
+		return nil, env.ErrEVNCoinNetValue
+	}
+
+	owner, err := solana.PublicKeyFromBase58(info.From)
+	if err != nil {
+		return nil, err
+	}
+
+	recipient, err := solana.PublicKeyFromBase58(info.To)
+	if err != nil {
+		return nil, err
+	}
+
+	mint, err := sol.SPLMint(tokenInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	destination, _, err := solana.FindAssociatedTokenAddress(recipient, mint)
+	if err != nil {
+		return nil, err
+	}
+
+	amount, err := info.GetAmount(tokenInfo.Decimal)
+	if err != nil {
+		return nil, err
+	}
+	if !amount.BigInt().IsUint64() {
+		return nil, ct.ErrAmountInvalid
+	}
+	info.Amount = amount.String()
+
+	client := sol.Client()
+
+	var (
+		recentBlockHash   *rpc.GetLatestBlockhashResult
+		source            solana.PublicKey
+		createDestination bool
+		computeUnitLimit  uint32
+		computeUnitPrice  uint64
+		lookupTables      []*sol.LookupTable
+	)
+	err = client.WithClient(ctx, func(_ctx context.Context, cli *rpc.Client) (bool, error) {
+		accounts, err := sol.GetTokenAccounts(_ctx, cli, owner, mint)
+		if err != nil {
+			return true, err
+		}
+
+		source, err = sol.SourceTokenAccount(accounts, owner, mint, amount.BigInt().Uint64())
+		if err != nil {
+			return false, err
+		}
+
+		_, err = cli.GetAccountInfo(_ctx, destination)
+		if err != nil && err != rpc.ErrNotFound {
+			return true, err
+		}
+		createDestination = err == rpc.ErrNotFound
+		computeUnitLimit = sol.SPLTransferComputeUnits
+		if createDestination {
+			computeUnitLimit = sol.SPLTransferComputeUnits + sol.CreateATAComputeUnits
+		}
+
+		computeUnitPrice, err = sol.EstimateComputeUnitPrice(_ctx, cli, []solana.PublicKey{owner, source, destination})
+		if err != nil {
+			return true, err
+		}
+
+		lookupTables, err = sol.GetTxLookupTables(_ctx, cli)
+		if err != nil {
+			return true, err
+		}
+
+		fee := sol.Fee(computeUnitLimit, computeUnitPrice)
+		if err := sol.CheckSPLLamports(_ctx, cli, owner, createDestination, fee); err != nil {
+			return !sol.TxFailErr(err), err
+		}
+
+		recentBlockHash, err = cli.GetLatestBlockhash(_ctx, rpc.CommitmentFinalized)
+		if err != nil || recentBlockHash == nil {
+			return true, err
+		}
+		return false, err
+	})
+	if err != nil {
+		return in, err
+	}
+
+	_out := sol.SignMsgTx{
+		BaseInfo:        info,
+		RecentBlockHash: recentBlockHash.Value.Blockhash.String(),
+		SPL: &sol.SPLTransfer{
+			Mint:              mint.String(),
+			Decimals:          uint8(tokenInfo.Decimal),
+			Source:            source.String(),
+			Destination:       destination.String(),
+			CreateDestination: createDestination,
+		},
+		ComputeUnitLimit:     computeUnitLimit,
+		ComputeUnitPrice:     computeUnitPrice,
+		LastValidBlockHeight: recentBlockHash.Value.LastValidBlockHeight,
+		Version:              sol.TxVersion(),
+		LookupTables:         lookupTables,
+	}
+
+	return json.Marshal(_out)
+}
@@ -0,0 +1,102 @@
+package sign
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/register"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/sol"
+	sol_sign "github.com/NpoolPlatform/sphinx-plugin/pkg/coins/sol/sign"
+	ct "github.com/NpoolPlatform/sphinx-plugin/pkg/types"
+	"github.com/gagliardetto/solana-go"
+	associatedtokenaccount "github.com/gagliardetto/solana-go/programs/associated-token-account"
+	"github.com/gagliardetto/solana-go/programs/token"
+)
+
+func init() {
+	register.RegisteTokenHandler(
+		coins.SPL,
+		register.OpWalletNew,
+		sol_sign.CreateAccount,
+	)
+	register.RegisteTokenHandler(
+		coins.SPL,
+		register.OpSign,
+		signTx,
+	)
+}
+
+var ErrSPLTransferInvalid = errors.New("spl transfer info is invalid")
+
+// signTx create the recipient associated token account when it is missing
+// and transfer the token by TransferChecked
+func signTx(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []byte, err error) {
+	info := sol.SignMsgTx{}
+	if err := json.Unmarshal(in, &info); err != nil {
+		return nil, err
+	}
+	if info.SPL == nil {
+		return nil, ErrSPLTransferInvalid
+	}
+
+	owner, err := solana.PublicKeyFromBase58(info.BaseInfo.From)
+	if err != nil {
+		return nil, err
+	}
+
+	recipient, err := solana.PublicKeyFromBase58(info.BaseInfo.To)
+	if err != nil {
+		return nil, err
+	}
+
+	mint, err := solana.PublicKeyFromBase58(info.SPL.Mint)
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := solana.PublicKeyFromBase58(info.SPL.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	// the destination must be the associated token account of the recipient
+	destination, _, err := solana.FindAssociatedTokenAddress(recipient, mint)
+	if err != nil {
+		return nil, err
+	}
+	if destination.String() != info.SPL.Destination {
+		return nil, ErrSPLTransferInvalid
+	}
+
+	amount, err := info.BaseInfo.GetAmount(int(info.SPL.Decimals))
+	if err != nil {
+		return nil, err
+	}
+	if !amount.BigInt().IsUint64() {
+		return nil, ct.ErrAmountInvalid
+	}
+
+	instructions := []solana.Instruction{}
+	if info.SPL.CreateDestination {
+		instructions = append(
+			instructions,
+			associatedtokenaccount.NewCreateInstruction(owner, recipient, mint).Build(),
+		)
+	}
+	instructions = append(
+		instructions,
+		token.NewTransferCheckedInstruction(
+			amount.BigInt().Uint64(),
+			info.SPL.Decimals,
+			source,
+			mint,
+			destination,
+			owner,
+			nil,
+		).Build(),
+	)
+
+	return sol_sign.SignInstructions(ctx, sol_sign.S3KeyPrxfix, &info, instructions)
+}
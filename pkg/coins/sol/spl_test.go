@@ -0,0 +1,35 @@
+package sol
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/test-go/testify/assert"
+)
+
+func TestSourceTokenAccount(t *testing.T) {
+	owner := solana.NewWallet().PublicKey()
+	mint := solana.MustPublicKeyFromBase58("EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v")
+	ata, _, err := solana.FindAssociatedTokenAddress(owner, mint)
+	assert.Nil(t, err)
+
+	other := solana.NewWallet().PublicKey()
+	accounts := []*TokenAccount{
+		{Address: other, Amount: 100},
+		{Address: ata, Amount: 50},
+	}
+
+	// the associated token account is preferred
+	source, err := SourceTokenAccount(accounts, owner, mint, 50)
+	assert.Nil(t, err)
+	assert.Equal(t, ata, source)
+
+	source, err = SourceTokenAccount(accounts, owner, mint, 80)
+	assert.Nil(t, err)
+	assert.Equal(t, other, source)
+
+	// the balance is not held by one account
+	_, err = SourceTokenAccount(accounts, owner, mint, 120)
+	assert.NotNil(t, err)
+	assert.True(t, TxFailErr(err))
+}
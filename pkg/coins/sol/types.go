@@ -7,8 +7,42 @@ import (
 type SignMsgTx struct {
 	BaseInfo        ct.BaseInfo `json:"base_info"`
 	RecentBlockHash string      `json:"recent_block_hash"`
+	// the spl token transfer, nil means the sol transfer
+	SPL *SPLTransfer `json:"spl,omitempty"`
+	// the compute budget of the transaction, the price is micro-lamports per compute unit
+	ComputeUnitLimit uint32 `json:"compute_unit_limit,omitempty"`
+	ComputeUnitPrice uint64 `json:"compute_unit_price,omitempty"`
+	// the transaction can not be on chain after the block height
+	LastValidBlockHeight uint64 `json:"last_valid_block_height,omitempty"`
+	// the v0 transaction loads the accounts from the lookup tables, empty means legacy
+	Version      string         `json:"version,omitempty"`
+	LookupTables []*LookupTable `json:"lookup_tables,omitempty"`
 }
 
 type BroadcastRequest struct {
 	Signature []byte `json:"signature"`
+	// carried to the sync, the expired transaction is built again by the base info
+	LastValidBlockHeight uint64       `json:"last_valid_block_height,omitempty"`
+	BaseInfo             *ct.BaseInfo `json:"base_info,omitempty"`
+}
+
+// SyncRequest the broadcast transaction, it is expired when the finalized block
+// height passes the last valid block height
+type SyncRequest struct {
+	TxID                 string       `json:"tx_id"`
+	LastValidBlockHeight uint64       `json:"last_valid_block_height,omitempty"`
+	BaseInfo             *ct.BaseInfo `json:"base_info,omitempty"`
+}
+
+// SPLTransfer the accounts of the spl token transfer, the sign builds the
+// TransferChecked instruction by it
+type SPLTransfer struct {
+	Mint     string `json:"mint"`
+	Decimals uint8  `json:"decimals"`
+	// the token account of the sender which covers the amount
+	Source string `json:"source"`
+	// the associated token account of the recipient
+	Destination string `json:"destination"`
+	// the destination is missing, it is created and paid by the sender
+	CreateDestination bool `json:"create_destination"`
 }
@@ -0,0 +1,311 @@
+package sol
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+)
+
+const (
+	// the message version, legacy message has no version prefix
+	TxVersionLegacy = "legacy"
+	TxVersion0      = "0"
+
+	// the highest bit of the first message byte marks the versioned message
+	versionPrefixMask = byte(0x80)
+	// the account index of the message is u8
+	maxAccountIndex = 255
+)
+
+var (
+	ErrTxVersionInvalid = errors.New("solana transaction version is invalid")
+	ErrTxDecodeFailed   = errors.New("solana transaction decode failed")
+	ErrTooManyAccounts  = errors.New("solana transaction has too many accounts")
+)
+
+// LookupTable the resolved address lookup table
+type LookupTable struct {
+	Address   string   `json:"address"`
+	Addresses []string `json:"addresses"`
+}
+
+// MessageAddressTableLookup the accounts loaded from the lookup table
+type MessageAddressTableLookup struct {
+	AccountKey      solana.PublicKey
+	WritableIndexes []uint8
+	ReadonlyIndexes []uint8
+}
+
+// MessageV0 the v0 message, the accounts which are not signer or program can be
+// loaded from the lookup tables
+type MessageV0 struct {
+	Header              solana.MessageHeader
+	AccountKeys         []solana.PublicKey
+	RecentBlockhash     solana.Hash
+	Instructions        []solana.CompiledInstruction
+	AddressTableLookups []MessageAddressTableLookup
+}
+
+type accountMeta struct {
+	key      solana.PublicKey
+	signer   bool
+	writable bool
+	invoked  bool
+}
+
+// NewMessageV0 compile the instructions to the v0 message, the payer is the first signer
+func NewMessageV0(instructions []solana.Instruction, payer solana.PublicKey, blockhash solana.Hash, tables []*LookupTable) (*MessageV0, error) {
+	metas := []*accountMeta{{key: payer, signer: true, writable: true}}
+	find := func(key solana.PublicKey) *accountMeta {
+		for _, v := range metas {
+			if v.key.Equals(key) {
+				return v
+			}
+		}
+		meta := &accountMeta{key: key}
+		metas = append(metas, meta)
+		return meta
+	}
+	for _, inst := range instructions {
+		find(inst.ProgramID()).invoked = true
+		for _, v := range inst.Accounts() {
+			meta := find(v.PublicKey)
+			meta.signer = meta.signer || v.IsSigner
+			meta.writable = meta.writable || v.IsWritable
+		}
+	}
+
+	message := &MessageV0{RecentBlockhash: blockhash}
+
+	// the accounts out of the static keys are loaded by the first table holding them
+	loaded := map[solana.PublicKey]bool{}
+	writableLoaded := []solana.PublicKey{}
+	readonlyLoaded := []solana.PublicKey{}
+	for _, table := range tables {
+		tableKey, err := solana.PublicKeyFromBase58(table.Address)
+		if err != nil {
+			return nil, err
+		}
+
+		lookup := MessageAddressTableLookup{AccountKey: tableKey}
+		for idx, addr := range table.Addresses {
+			if idx > maxAccountIndex {
+				break
+			}
+			key, err := solana.PublicKeyFromBase58(addr)
+			if err != nil {
+				return nil, err
+			}
+			for _, meta := range metas {
+				if meta.signer || meta.invoked || loaded[meta.key] || !meta.key.Equals(key) {
+					continue
+				}
+				loaded[meta.key] = true
+				if meta.writable {
+					lookup.WritableIndexes = append(lookup.WritableIndexes, uint8(idx))
+					writableLoaded = append(writableLoaded, key)
+				} else {
+					lookup.ReadonlyIndexes = append(lookup.ReadonlyIndexes, uint8(idx))
+					readonlyLoaded = append(readonlyLoaded, key)
+				}
+			}
+		}
+		if len(lookup.WritableIndexes) > 0 || len(lookup.ReadonlyIndexes) > 0 {
+			message.AddressTableLookups = append(message.AddressTableLookups, lookup)
+		}
+	}
+
+	// the static keys: writable signers, readonly signers, writable and readonly non-signers
+	for _, group := range []struct{ signer, writable bool }{
+		{true, true}, {true, false}, {false, true}, {false, false},
+	} {
+		for _, meta := range metas {
+			if loaded[meta.key] || meta.signer != group.signer || meta.writable != group.writable {
+				continue
+			}
+			message.AccountKeys = append(message.AccountKeys, meta.key)
+			switch {
+			case meta.signer && meta.writable:
+				message.Header.NumRequiredSignatures++
+			case meta.signer:
+				message.Header.NumRequiredSignatures++
+				message.Header.NumReadonlySignedAccounts++
+			case !meta.writable:
+				message.Header.NumReadonlyUnsignedAccounts++
+			}
+		}
+	}
+
+	// the index of the loaded accounts follows the static keys, writable first
+	indexes := map[solana.PublicKey]int{}
+	for _, keys := range [][]solana.PublicKey{message.AccountKeys, writableLoaded, readonlyLoaded} {
+		for _, key := range keys {
+			indexes[key] = len(indexes)
+		}
+	}
+	if len(indexes) > maxAccountIndex+1 {
+		return nil, ErrTooManyAccounts
+	}
+
+	for _, inst := range instructions {
+		data, err := inst.Data()
+		if err != nil {
+			return nil, err
+		}
+		compiled := solana.CompiledInstruction{
+			ProgramIDIndex: uint16(indexes[inst.ProgramID()]),
+			Data:           data,
+		}
+		for _, v := range inst.Accounts() {
+			compiled.Accounts = append(compiled.Accounts, uint16(indexes[v.PublicKey]))
+		}
+		message.Instructions = append(message.Instructions, compiled)
+	}
+
+	return message, nil
+}
+
+// MarshalBinary the message bytes which are signed
+func (message *MessageV0) MarshalBinary() ([]byte, error) {
+	buf := []byte{
+		versionPrefixMask, // version 0
+		message.Header.NumRequiredSignatures,
+		message.Header.NumReadonlySignedAccounts,
+		message.Header.NumReadonlyUnsignedAccounts,
+	}
+
+	bin.EncodeCompactU16Length(&buf, len(message.AccountKeys))
+	for _, key := range message.AccountKeys {
+		buf = append(buf, key[:]...)
+	}
+	buf = append(buf, message.RecentBlockhash[:]...)
+
+	bin.EncodeCompactU16Length(&buf, len(message.Instructions))
+	for _, inst := range message.Instructions {
+		buf = append(buf, byte(inst.ProgramIDIndex))
+		bin.EncodeCompactU16Length(&buf, len(inst.Accounts))
+		for _, idx := range inst.Accounts {
+			buf = append(buf, byte(idx))
+		}
+		bin.EncodeCompactU16Length(&buf, len(inst.Data))
+		buf = append(buf, inst.Data...)
+	}
+
+	bin.EncodeCompactU16Length(&buf, len(message.AddressTableLookups))
+	for _, lookup := range message.AddressTableLookups {
+		buf = append(buf, lookup.AccountKey[:]...)
+		bin.EncodeCompactU16Length(&buf, len(lookup.WritableIndexes))
+		buf = append(buf, lookup.WritableIndexes...)
+		bin.EncodeCompactU16Length(&buf, len(lookup.ReadonlyIndexes))
+		buf = append(buf, lookup.ReadonlyIndexes...)
+	}
+	return buf, nil
+}
+
+// SignMessageV0 sign the message by the signers and encode the transaction
+func SignMessageV0(message *MessageV0, getter func(key solana.PublicKey) *solana.PrivateKey) ([]byte, error) {
+	msg, err := message.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	buf := []byte{}
+	bin.EncodeCompactU16Length(&buf, int(message.Header.NumRequiredSignatures))
+	for _, key := range message.AccountKeys[:message.Header.NumRequiredSignatures] {
+		privateKey := getter(key)
+		if privateKey == nil {
+			return nil, fmt.Errorf("signer key %v not found", key)
+		}
+		signature, err := privateKey.Sign(msg)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, signature[:]...)
+	}
+	return append(buf, msg...), nil
+}
+
+// RawTransaction the signed legacy or versioned transaction
+type RawTransaction struct {
+	Signatures []solana.Signature
+	// TxVersionLegacy or TxVersion0
+	Version     string
+	Header      solana.MessageHeader
+	AccountKeys []solana.PublicKey
+	// the bytes which are signed
+	Message []byte
+}
+
+// DecodeRawTransaction decode the signatures and the static keys of the transaction
+func DecodeRawTransaction(raw []byte) (*RawTransaction, error) {
+	reader := bytes.NewReader(raw)
+	sigNum, err := bin.DecodeCompactU16LengthFromByteReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("%v, %v", ErrTxDecodeFailed, err)
+	}
+
+	tx := &RawTransaction{Version: TxVersionLegacy}
+	for i := 0; i < sigNum; i++ {
+		signature := solana.Signature{}
+		if _, err := io.ReadFull(reader, signature[:]); err != nil {
+			return nil, fmt.Errorf("%v, %v", ErrTxDecodeFailed, err)
+		}
+		tx.Signatures = append(tx.Signatures, signature)
+	}
+	tx.Message = raw[len(raw)-reader.Len():]
+
+	prefix, err := reader.ReadByte()
+	if err != nil {
+		return nil, fmt.Errorf("%v, %v", ErrTxDecodeFailed, err)
+	}
+	if prefix&versionPrefixMask != 0 {
+		if prefix != versionPrefixMask {
+			return nil, fmt.Errorf("%v, version %v", ErrTxVersionInvalid, prefix&^versionPrefixMask)
+		}
+		tx.Version = TxVersion0
+		if prefix, err = reader.ReadByte(); err != nil {
+			return nil, fmt.Errorf("%v, %v", ErrTxDecodeFailed, err)
+		}
+	}
+
+	header := []byte{prefix, 0, 0}
+	if _, err := io.ReadFull(reader, header[1:]); err != nil {
+		return nil, fmt.Errorf("%v, %v", ErrTxDecodeFailed, err)
+	}
+	tx.Header = solana.MessageHeader{
+		NumRequiredSignatures:       header[0],
+		NumReadonlySignedAccounts:   header[1],
+		NumReadonlyUnsignedAccounts: header[2],
+	}
+
+	keyNum, err := bin.DecodeCompactU16LengthFromByteReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("%v, %v", ErrTxDecodeFailed, err)
+	}
+	for i := 0; i < keyNum; i++ {
+		key := solana.PublicKey{}
+		if _, err := io.ReadFull(reader, key[:]); err != nil {
+			return nil, fmt.Errorf("%v, %v", ErrTxDecodeFailed, err)
+		}
+		tx.AccountKeys = append(tx.AccountKeys, key)
+	}
+	return tx, nil
+}
+
+// VerifySignatures the signatures are signed by the first static keys
+func (tx *RawTransaction) VerifySignatures() error {
+	if len(tx.Signatures) != int(tx.Header.NumRequiredSignatures) ||
+		len(tx.AccountKeys) < len(tx.Signatures) {
+		return ErrSolSignatureWrong
+	}
+	for i, signature := range tx.Signatures {
+		if !signature.Verify(tx.AccountKeys[i], tx.Message) {
+			return ErrSolSignatureWrong
+		}
+	}
+	return nil
+}
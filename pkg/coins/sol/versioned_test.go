@@ -0,0 +1,103 @@
+package sol
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/test-go/testify/assert"
+)
+
+func TestDecodeLegacyTransaction(t *testing.T) {
+	from := solana.NewWallet().PrivateKey
+	to := solana.NewWallet().PublicKey()
+
+	tx, err := solana.NewTransaction(
+		[]solana.Instruction{system.NewTransferInstruction(1, from.PublicKey(), to).Build()},
+		solana.Hash{1},
+		solana.TransactionPayer(from.PublicKey()),
+	)
+	assert.Nil(t, err)
+	_, err = tx.Sign(func(key solana.PublicKey) *solana.PrivateKey { return &from })
+	assert.Nil(t, err)
+
+	buf := bytes.Buffer{}
+	assert.Nil(t, tx.MarshalWithEncoder(bin.NewBinEncoder(&buf)))
+
+	raw, err := DecodeRawTransaction(buf.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, TxVersionLegacy, raw.Version)
+	assert.Equal(t, tx.Message.AccountKeys, raw.AccountKeys)
+	assert.Nil(t, raw.VerifySignatures())
+}
+
+func TestMessageV0(t *testing.T) {
+	from := solana.NewWallet().PrivateKey
+	to := solana.NewWallet().PublicKey()
+	table := &LookupTable{
+		Address:   solana.NewWallet().PublicKey().String(),
+		Addresses: []string{solana.NewWallet().PublicKey().String(), to.String()},
+	}
+
+	instructions := append(
+		ComputeBudgetInstructions(SOLTransferComputeUnits, 1),
+		system.NewTransferInstruction(1, from.PublicKey(), to).Build(),
+	)
+	message, err := NewMessageV0(instructions, from.PublicKey(), solana.Hash{1}, []*LookupTable{table})
+	assert.Nil(t, err)
+
+	// the recipient is loaded from the table, the programs are static
+	assert.Equal(t, []solana.PublicKey{from.PublicKey(), ComputeBudgetProgramID, solana.SystemProgramID}, message.AccountKeys)
+	assert.Equal(t, solana.MessageHeader{NumRequiredSignatures: 1, NumReadonlyUnsignedAccounts: 2}, message.Header)
+	assert.Equal(t, 1, len(message.AddressTableLookups))
+	assert.Equal(t, []uint8{1}, message.AddressTableLookups[0].WritableIndexes)
+	assert.Equal(t, 0, len(message.AddressTableLookups[0].ReadonlyIndexes))
+	assert.Equal(t, []uint16{0, 3}, message.Instructions[2].Accounts)
+	assert.Equal(t, uint16(2), message.Instructions[2].ProgramIDIndex)
+
+	signed, err := SignMessageV0(message, func(key solana.PublicKey) *solana.PrivateKey {
+		if key.Equals(from.PublicKey()) {
+			return &from
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+
+	raw, err := DecodeRawTransaction(signed)
+	assert.Nil(t, err)
+	assert.Equal(t, TxVersion0, raw.Version)
+	assert.Equal(t, message.AccountKeys, raw.AccountKeys)
+	assert.Nil(t, raw.VerifySignatures())
+
+	signed[len(signed)-1] ^= 0xff
+	raw, err = DecodeRawTransaction(signed)
+	assert.Nil(t, err)
+	assert.NotNil(t, raw.VerifySignatures())
+}
+
+func TestParseLookupTable(t *testing.T) {
+	address := solana.NewWallet().PublicKey()
+	key := solana.NewWallet().PublicKey()
+
+	data := make([]byte, lookupTableMetaSize)
+	binary.LittleEndian.PutUint32(data[0:4], lookupTableType)
+	binary.LittleEndian.PutUint64(data[4:12], math.MaxUint64)
+	data = append(data, key[:]...)
+
+	table, err := ParseLookupTable(address, data)
+	assert.Nil(t, err)
+	assert.Equal(t, address.String(), table.Address)
+	assert.Equal(t, []string{key.String()}, table.Addresses)
+
+	// deactivated
+	binary.LittleEndian.PutUint64(data[4:12], 100)
+	_, err = ParseLookupTable(address, data)
+	assert.NotNil(t, err)
+
+	_, err = ParseLookupTable(address, data[:lookupTableMetaSize+1])
+	assert.NotNil(t, err)
+}
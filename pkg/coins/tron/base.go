@@ -2,6 +2,7 @@ package tron
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
@@ -9,28 +10,25 @@ import (
 	"math/big"
 	"strings"
 
+	bc_client "github.com/NpoolPlatform/build-chain/pkg/client/v1"
+	"github.com/NpoolPlatform/go-service-framework/pkg/logger"
+	"github.com/NpoolPlatform/libent-cruder/pkg/cruder"
 	v1 "github.com/NpoolPlatform/message/npool/basetypes/v1"
+	proto "github.com/NpoolPlatform/message/npool/build-chain/v1"
 	"github.com/NpoolPlatform/message/npool/sphinxplugin"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/register"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/env"
 	"github.com/btcsuite/btcutil/base58"
-	"github.com/shopspring/decimal"
 )
 
 const (
-	TRC20ACCURACY = 6
-	TRXACCURACY   = 6
-
-	// feeLimit-10^6=1trx
-	TRC20FeeLimit int64 = 30000000
-
-	txExpired              = `Transaction expired`
 	fundsToLow             = `balance is not sufficient`
 	AddressNotActive       = `account not found`
 	AddressInvalid         = `address is invalid`
 	GetAccountFailed       = `the tron node get account failed`
 	BuildTransactionFailed = `the tron node build transaction failed`
+	FeeLimitTooLow         = `the max fee limit is lower than the energy fee`
 
 	ChainType           = sphinxplugin.ChainType_Tron
 	ChainNativeUnit     = "TRX"
@@ -38,6 +36,8 @@ const (
 	ChainUnitExp        = 6
 	ChainNativeCoinName = "tron"
 	ChainID             = "728126428"
+
+	USDTContract = "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"
 )
 
 var (
@@ -47,25 +47,17 @@ var (
 	AddressSize            = 42
 	AddressPreFixByte byte = 0x41
 
-	stopErrs = []string{txExpired, fundsToLow, AddressInvalid, AddressNotActive, BuildTransactionFailed}
-
-	USDTContract = func(chainet string) string {
-		switch chainet {
-		case coins.CoinNetMain:
-			return "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"
-		case coins.CoinNetTest:
-			contract, ok := env.LookupEnv(env.ENVCONTRACT)
-			if !ok {
-				panic(env.ErrENVContractNotFound)
-			}
-			return contract
-		}
-		return ""
+	errorMessages = []register.ErrorMessage{
+		{Message: fundsToLow, Kind: register.ErrorKindInsufficientFunds},
+		{Message: AddressInvalid, Kind: register.ErrorKindInvalidAddress},
+		{Message: AddressNotActive, Kind: register.ErrorKindInvalidAddress},
+		{Message: BuildTransactionFailed, Kind: register.ErrorKindPermanent},
+		{Message: FeeLimitTooLow, Kind: register.ErrorKindPermanent},
 	}
 
 	tronTokenList = []*coins.TokenInfo{
 		{OfficialName: "Tron", Decimal: 6, Unit: "TRX", Name: ChainNativeCoinName, OfficialContract: ChainNativeCoinName, TokenType: coins.Tron, CoinType: sphinxplugin.CoinType_CoinTypetron},
-		{OfficialName: "Tether USD", Decimal: 6, Unit: "USDT", Name: "usdttrc20", OfficialContract: "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t", TokenType: coins.Trc20, CoinType: sphinxplugin.CoinType_CoinTypeusdttrc20},
+		{OfficialName: "Tether USD", Decimal: 6, Unit: "USDT", Name: "usdttrc20", OfficialContract: USDTContract, TokenType: coins.Trc20, CoinType: sphinxplugin.CoinType_CoinTypeusdttrc20},
 	}
 )
 
@@ -76,7 +68,7 @@ func init() {
 		token.ChainNativeUnit = ChainNativeUnit
 		token.ChainAtomicUnit = ChainAtomicUnit
 		token.ChainUnitExp = ChainUnitExp
-		token.GasType = v1.GasType_GasUnsupported
+		token.GasType = v1.GasType_DynamicGas
 		token.ChainID = ChainID
 		token.ChainNickname = ChainType.String()
 		token.ChainNativeCoinName = ChainNativeCoinName
@@ -86,22 +78,93 @@ func init() {
 		token.Contract = token.OfficialContract
 		register.RegisteTokenInfo(token)
 	}
+
+	// the trc20 tokens of the trc20 plugin use the coin type of tron
+	register.RegisteTokenNetHandler(sphinxplugin.CoinType_CoinTypettron, netHandle)
+	register.RegisteTokenNetHandler(sphinxplugin.CoinType_CoinTypetusdttrc20, netHandle)
 }
 
-func TRC20ToBigInt(value float64) *big.Int {
-	return decimal.NewFromFloat(value).Mul(decimal.NewFromBigInt(big.NewInt(1), TRC20ACCURACY)).BigInt()
+// netHandle set the test contracts of the trc20 tokens which deployed by build-chain,
+// the usdttrc20 use the ENV_CONTRACT when it is not deployed by build-chain
+func netHandle(tokenInfos []*coins.TokenInfo) error {
+	contracts, err := buildChainContracts()
+	if err != nil {
+		return err
+	}
+
+	if contract, ok := env.LookupEnv(env.ENVCONTRACT); ok {
+		if _, ok := contracts[USDTContract]; !ok {
+			contracts[USDTContract] = contract
+		}
+	}
+
+	SetTestContracts(tokenInfos, contracts)
+	return nil
 }
 
-func TRC20ToBigFloat(value *big.Int) *big.Float {
-	return decimal.NewFromBigInt(value, 0).Div(decimal.NewFromBigInt(big.NewInt(1), TRC20ACCURACY)).BigFloat()
+// buildChainContracts the private contracts of the trc20 tokens by the official contract,
+// the tron plugin can run without build-chain, then no trc20 token is deployed
+func buildChainContracts() (map[string]string, error) {
+	contracts := make(map[string]string)
+	bcServer, ok := env.LookupEnv(env.ENVBUIILDCHIANSERVER)
+	if !ok {
+		return contracts, nil
+	}
+
+	ctx := context.Background()
+	bcConn, bcConnErr := bc_client.NewClientConn(ctx, bcServer)
+	if bcConnErr != nil {
+		logger.Sugar().Error(bcConnErr)
+		return nil, fmt.Errorf("connect server failed, %v", bcConnErr)
+	}
+	defer bcConn.Close()
+	trc20List, err := bcConn.GetTokenInfos(ctx, &proto.GetTokenInfosRequest{
+		Conds: &proto.Conds{
+			TokenType: &v1.StringVal{
+				Op:    cruder.EQ,
+				Value: string(coins.Trc20),
+			},
+		},
+	})
+	if err != nil {
+		logger.Sugar().Error(err)
+		return nil, fmt.Errorf("failed to get token infos from build-chain, err: %v", err)
+	}
+
+	for _, info := range trc20List.Infos {
+		if info.PrivateContract != "" {
+			contracts[info.OfficialContract] = info.PrivateContract
+		}
+	}
+	return contracts, nil
 }
 
-func TRXToInt(value float64) int64 {
-	return decimal.NewFromFloat(value).Mul(decimal.NewFromBigInt(big.NewInt(1), TRXACCURACY)).BigInt().Int64()
+// SetTestContracts set the test contracts of the trc20 tokens by the official contract,
+// the token without test contract is not registered
+func SetTestContracts(tokenInfos []*coins.TokenInfo, contracts map[string]string) {
+	for _, v := range tokenInfos {
+		if v.TokenType == coins.Tron {
+			v.DisableRegiste = false
+			continue
+		}
+		if contract, ok := contracts[v.OfficialContract]; ok {
+			v.DisableRegiste = false
+			v.Contract = contract
+		}
+	}
 }
 
-func TRXToBigFloat(value int64) *big.Float {
-	return decimal.NewFromInt(value).Div(decimal.NewFromBigInt(big.NewInt(1), TRXACCURACY)).BigFloat()
+// TRC20Contract the contract of the trc20 token, the test token get it by the net handler
+func TRC20Contract(tokenInfo *coins.TokenInfo) (string, error) {
+	contract := tokenInfo.Contract
+	if contract == "" {
+		return "", env.ErrContractInvalid
+	}
+
+	if err := ValidAddress(contract); err != nil {
+		return "", fmt.Errorf("contract %v, %v, %v", contract, AddressInvalid, err)
+	}
+	return contract, nil
 }
 
 func ValidAddress(input string) error {
@@ -178,15 +241,11 @@ func decodeFromBase58Check(input string) ([]byte, error) {
 	return address, nil
 }
 
-func TxFailErr(err error) bool {
-	if err == nil {
-		return false
-	}
+// ClassifyErr the kind of the node error
+func ClassifyErr(err error) register.ErrorKind {
+	return register.MessageClassifier(errorMessages...)(err)
+}
 
-	for _, v := range stopErrs {
-		if strings.Contains(err.Error(), v) {
-			return true
-		}
-	}
-	return false
+func TxFailErr(err error) bool {
+	return ClassifyErr(err).Abort()
 }
@@ -0,0 +1,57 @@
+package tron
+
+import (
+	"os"
+	"testing"
+
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/env"
+	"github.com/test-go/testify/assert"
+)
+
+func testTokenInfos() []*coins.TokenInfo {
+	return []*coins.TokenInfo{
+		{TokenType: coins.Tron, Net: coins.CoinNetTest, OfficialContract: ChainNativeCoinName, DisableRegiste: true},
+		{TokenType: coins.Trc20, Net: coins.CoinNetTest, OfficialContract: USDTContract, DisableRegiste: true},
+		{TokenType: coins.Trc20, Net: coins.CoinNetTest, OfficialContract: "TEkxiTehnzSmSe2XqrBj4w32RUN966rdz8", DisableRegiste: true},
+	}
+}
+
+func TestSetTestContracts(t *testing.T) {
+	private := "TXYZopYRdj2D9XRtbG411XZZ3kM5VkAeBf"
+	tokenInfos := testTokenInfos()
+	SetTestContracts(tokenInfos, map[string]string{"TEkxiTehnzSmSe2XqrBj4w32RUN966rdz8": private})
+
+	assert.False(t, tokenInfos[0].DisableRegiste)
+	// the token without test contract is not registered and has no contract
+	assert.True(t, tokenInfos[1].DisableRegiste)
+	_, err := TRC20Contract(tokenInfos[1])
+	assert.NotNil(t, err)
+
+	assert.False(t, tokenInfos[2].DisableRegiste)
+	contract, err := TRC20Contract(tokenInfos[2])
+	assert.Nil(t, err)
+	assert.Equal(t, private, contract)
+}
+
+func TestNetHandle(t *testing.T) {
+	private := "TXYZopYRdj2D9XRtbG411XZZ3kM5VkAeBf"
+	os.Unsetenv(env.ENVBUIILDCHIANSERVER)
+	os.Setenv(env.ENVCONTRACT, private)
+	defer os.Unsetenv(env.ENVCONTRACT)
+
+	// the ENV_CONTRACT is only the test contract of the usdttrc20
+	tokenInfos := testTokenInfos()
+	assert.Nil(t, netHandle(tokenInfos))
+	assert.False(t, tokenInfos[0].DisableRegiste)
+	assert.False(t, tokenInfos[1].DisableRegiste)
+	assert.Equal(t, private, tokenInfos[1].Contract)
+	assert.True(t, tokenInfos[2].DisableRegiste)
+	assert.Equal(t, "", tokenInfos[2].Contract)
+
+	os.Unsetenv(env.ENVCONTRACT)
+	tokenInfos = testTokenInfos()
+	assert.Nil(t, netHandle(tokenInfos))
+	assert.False(t, tokenInfos[0].DisableRegiste)
+	assert.True(t, tokenInfos[1].DisableRegiste)
+}
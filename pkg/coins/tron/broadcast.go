@@ -0,0 +1,23 @@
+package tron
+
+import (
+	"strings"
+
+	tronclient "github.com/Geapefurit/gotron-sdk/pkg/client"
+)
+
+// the node return it when the transaction is not on chain
+const txInfoNotFound = `transaction info not found`
+
+// Broadcasted the transaction is already on chain, the expired transaction
+// which is on chain must not be rebuilt
+func Broadcasted(cli *tronclient.GrpcClient, txID string) (bool, error) {
+	_, err := cli.GetTransactionInfoByID(txID)
+	if err != nil && strings.Contains(err.Error(), txInfoNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
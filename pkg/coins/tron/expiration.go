@@ -0,0 +1,33 @@
+package tron
+
+import (
+	"time"
+
+	tronclient "github.com/Geapefurit/gotron-sdk/pkg/client"
+	"github.com/Geapefurit/gotron-sdk/pkg/proto/api"
+	"github.com/Geapefurit/gotron-sdk/pkg/proto/core"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/config"
+)
+
+// DefaultTxExpiration the expiration window of the built transaction, the node
+// default is 60 seconds which is too short for the sign and broadcast pipeline
+const DefaultTxExpiration = 10 * time.Minute
+
+// TxExpiration the expiration window of the transaction from built
+func TxExpiration() time.Duration {
+	if envInfo := config.GetENV(); envInfo != nil && envInfo.TronTxExpiration > 0 {
+		return time.Duration(envInfo.TronTxExpiration) * time.Second
+	}
+	return DefaultTxExpiration
+}
+
+// SetExpiration reset the expiration of the unsigned transaction and update the tx id
+func SetExpiration(cli *tronclient.GrpcClient, txExtension *api.TransactionExtention) error {
+	txExtension.GetTransaction().GetRawData().Expiration = time.Now().Add(TxExpiration()).UnixMilli()
+	return cli.UpdateHash(txExtension)
+}
+
+// IsExpired the transaction can not be accepted by the node any more
+func IsExpired(transaction *core.Transaction) bool {
+	return transaction.GetRawData().GetExpiration() <= time.Now().UnixMilli()
+}
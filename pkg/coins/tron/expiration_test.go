@@ -0,0 +1,28 @@
+package tron
+
+import (
+	"testing"
+	"time"
+
+	tronclient "github.com/Geapefurit/gotron-sdk/pkg/client"
+	"github.com/Geapefurit/gotron-sdk/pkg/proto/api"
+	"github.com/Geapefurit/gotron-sdk/pkg/proto/core"
+	"github.com/test-go/testify/assert"
+)
+
+func TestSetExpiration(t *testing.T) {
+	txExtension := &api.TransactionExtention{
+		Transaction: &core.Transaction{
+			RawData: &core.TransactionRaw{
+				Expiration: time.Now().Add(-time.Second).UnixMilli(),
+			},
+		},
+	}
+	assert.True(t, IsExpired(txExtension.Transaction))
+
+	err := SetExpiration(&tronclient.GrpcClient{}, txExtension)
+	assert.Nil(t, err)
+	assert.False(t, IsExpired(txExtension.Transaction))
+	assert.True(t, txExtension.Transaction.RawData.Expiration > time.Now().Add(DefaultTxExpiration-time.Minute).UnixMilli())
+	assert.Equal(t, 32, len(txExtension.Txid))
+}
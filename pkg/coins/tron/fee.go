@@ -0,0 +1,295 @@
+package tron
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/Geapefurit/gotron-sdk/pkg/address"
+	tronclient "github.com/Geapefurit/gotron-sdk/pkg/client"
+	tcommon "github.com/Geapefurit/gotron-sdk/pkg/common"
+	"github.com/Geapefurit/gotron-sdk/pkg/proto/api"
+	"github.com/NpoolPlatform/message/npool/sphinxproxy"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/config"
+	ct "github.com/NpoolPlatform/sphinx-plugin/pkg/types"
+	"github.com/ethereum/go-ethereum/common"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// chain parameters, sun per energy and sun per bandwidth
+	paramEnergyFee      = "getEnergyFee"
+	paramTransactionFee = "getTransactionFee"
+	// the bandwidth fee and the system contract fee of activating the account
+	paramCreateAccountFee    = "getCreateAccountFee"
+	paramCreateNewAccountFee = "getCreateNewAccountFeeInSystemContract"
+
+	// the default of the chain parameters when the node not return them
+	DefaultEnergyFee        = int64(420)
+	DefaultTransactionFee   = int64(1000)
+	DefaultCreateAccountFee = int64(100_000)
+	DefaultNewAccountFee    = int64(1_000_000)
+	// DefaultTRC20Energy the energy of the trc20 transfer when the node can not estimate
+	DefaultTRC20Energy = int64(65_000)
+	// DefaultMaxFeeLimit sun, the upper limit of the trc20 fee limit
+	DefaultMaxFeeLimit = int64(100_000_000)
+	// percent of the energy fee
+	feeLimitTolerance = 120
+
+	// the bandwidth of the trx and trc20 transfer for the estimate-gas handler
+	TransferBandwidth = int64(268)
+	TRC20Bandwidth    = int64(345)
+
+	// signature 65 bytes with the field tag and length
+	signatureSize = 67
+	// the result in the transaction counted by the bandwidth
+	maxResultSize = 64
+	// energy_used of TransactionExtention, the field is unknown to the gotron-sdk proto
+	energyUsedField protowire.Number = 5
+
+	trc20TransferMethod = "0xa9059cbb"
+)
+
+var ErrEstimateEnergyFailed = errors.New("the tron node estimate energy failed")
+
+// ChainParams the resource price of the chain
+type ChainParams struct {
+	EnergyFee        int64
+	TransactionFee   int64
+	CreateAccountFee int64
+	NewAccountFee    int64
+}
+
+// FeeEstimate the resources and the trx burned of the transaction
+type FeeEstimate struct {
+	Energy    int64 `json:"energy"`
+	Bandwidth int64 `json:"bandwidth"`
+	// sun burned when the staked resources is not enough
+	EnergyFee    int64 `json:"energy_fee"`
+	BandwidthFee int64 `json:"bandwidth_fee"`
+	// sun burned by activating the recipient
+	ActivationFee int64 `json:"activation_fee,omitempty"`
+	Fee           int64 `json:"fee"`
+	// sun, the upper limit of the trx burned by the energy
+	FeeLimit int64 `json:"fee_limit,omitempty"`
+}
+
+// MaxFeeLimit the upper limit of the trc20 fee limit(sun)
+func MaxFeeLimit() int64 {
+	if envInfo := config.GetENV(); envInfo != nil && envInfo.TronMaxFeeLimit > 0 {
+		return int64(envInfo.TronMaxFeeLimit * 1_000_000)
+	}
+	return DefaultMaxFeeLimit
+}
+
+func GetChainParams(ctx context.Context, cli *tronclient.GrpcClient) (*ChainParams, error) {
+	params, err := cli.Client.GetChainParameters(ctx, &api.EmptyMessage{})
+	if err != nil {
+		return nil, err
+	}
+
+	chainParams := &ChainParams{
+		EnergyFee:        DefaultEnergyFee,
+		TransactionFee:   DefaultTransactionFee,
+		CreateAccountFee: DefaultCreateAccountFee,
+		NewAccountFee:    DefaultNewAccountFee,
+	}
+	for _, param := range params.GetChainParameter() {
+		switch param.GetKey() {
+		case paramEnergyFee:
+			chainParams.EnergyFee = param.GetValue()
+		case paramTransactionFee:
+			chainParams.TransactionFee = param.GetValue()
+		case paramCreateAccountFee:
+			chainParams.CreateAccountFee = param.GetValue()
+		case paramCreateNewAccountFee:
+			chainParams.NewAccountFee = param.GetValue()
+		}
+	}
+	return chainParams, nil
+}
+
+// EstimateBandwidth the bandwidth of the signed transaction
+func EstimateBandwidth(txExtension *api.TransactionExtention) (int64, error) {
+	raw, err := proto.Marshal(txExtension.GetTransaction())
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(raw)) + signatureSize + maxResultSize, nil
+}
+
+// EstimateTRC20Energy estimate the energy of the trc20 transfer by TriggerConstantContract
+func EstimateTRC20Energy(cli *tronclient.GrpcClient, from, to, contract string, amount *big.Int) (int64, error) {
+	toAddr, err := address.Base58ToAddress(to)
+	if err != nil {
+		return 0, err
+	}
+
+	data := trc20TransferMethod +
+		tcommon.Bytes2Hex(common.LeftPadBytes(toAddr.Bytes()[1:], 32)) +
+		tcommon.Bytes2Hex(common.LeftPadBytes(amount.Bytes(), 32))
+	txExtension, err := cli.TRC20Call(from, contract, data, true, 0)
+	if err != nil {
+		return 0, err
+	}
+	if !txExtension.GetResult().GetResult() {
+		return 0, fmt.Errorf("%v, %v", ErrEstimateEnergyFailed, string(txExtension.GetResult().GetMessage()))
+	}
+
+	if energy, ok := energyUsed(txExtension); ok && energy > 0 {
+		return energy, nil
+	}
+	return DefaultTRC20Energy, nil
+}
+
+func energyUsed(txExtension *api.TransactionExtention) (int64, bool) {
+	unknown := txExtension.ProtoReflect().GetUnknown()
+	for len(unknown) > 0 {
+		num, typ, n := protowire.ConsumeTag(unknown)
+		if n < 0 {
+			return 0, false
+		}
+		unknown = unknown[n:]
+
+		if num == energyUsedField && typ == protowire.VarintType {
+			v, n := protowire.ConsumeVarint(unknown)
+			if n < 0 {
+				return 0, false
+			}
+			return int64(v), true
+		}
+
+		n = protowire.ConsumeFieldValue(num, typ, unknown)
+		if n < 0 {
+			return 0, false
+		}
+		unknown = unknown[n:]
+	}
+	return 0, false
+}
+
+// IsActivated the account is activated on chain, the inactive account is not found
+func IsActivated(cli *tronclient.GrpcClient, addr string) (bool, error) {
+	_, err := cli.GetAccount(addr)
+	if err != nil && strings.Contains(err.Error(), AddressNotActive) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// EstimateFee price the energy and the bandwidth, the staked resources of the
+// account are used first, activate means the transfer creates the recipient
+func EstimateFee(cli *tronclient.GrpcClient, from string, energy, bandwidth int64, activate bool, params *ChainParams) (*FeeEstimate, error) {
+	resource, err := cli.GetAccountResource(from)
+	if err != nil && !strings.Contains(err.Error(), AddressNotActive) {
+		return nil, err
+	}
+	return estimateFee(resource, energy, bandwidth, activate, params)
+}
+
+func estimateFee(resource *api.AccountResourceMessage, energy, bandwidth int64, activate bool, params *ChainParams) (*FeeEstimate, error) {
+	var (
+		freeNet         int64
+		net             int64
+		availableEnergy int64
+	)
+	if resource != nil {
+		freeNet = resource.GetFreeNetLimit() - resource.GetFreeNetUsed()
+		net = resource.GetNetLimit() - resource.GetNetUsed()
+		availableEnergy = resource.GetEnergyLimit() - resource.GetEnergyUsed()
+	}
+
+	estimate := &FeeEstimate{
+		Energy:    energy,
+		Bandwidth: bandwidth,
+	}
+
+	// the bandwidth is paid by one source wholly
+	if bandwidth > freeNet && bandwidth > net {
+		estimate.BandwidthFee = bandwidth * params.TransactionFee
+	}
+	// activating can not use the free bandwidth, it burns the fixed fee instead
+	if activate {
+		estimate.ActivationFee = params.NewAccountFee
+		estimate.BandwidthFee = 0
+		if bandwidth > net {
+			estimate.BandwidthFee = params.CreateAccountFee
+		}
+	}
+	if energy > availableEnergy {
+		estimate.EnergyFee = (energy - availableEnergy) * params.EnergyFee
+	}
+	estimate.Fee = estimate.EnergyFee + estimate.BandwidthFee + estimate.ActivationFee
+
+	if energy > 0 {
+		var err error
+		estimate.FeeLimit, err = FeeLimit(energy, activate, params)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return estimate, nil
+}
+
+// ActivationCost the most trx burned by activating the recipient, the fixed bandwidth
+// fee and the system contract fee
+func ActivationCost(params *ChainParams) int64 {
+	return params.CreateAccountFee + params.NewAccountFee
+}
+
+// FeeLimit the fee limit of the trc20 transfer, the staked energy may be used by
+// other transactions before this one, so it covers all the energy with the tolerance,
+// the activation of the recipient is covered when activate
+func FeeLimit(energy int64, activate bool, params *ChainParams) (int64, error) {
+	need := energy * params.EnergyFee
+	feeLimit := need * feeLimitTolerance / 100
+	if activate {
+		need += ActivationCost(params)
+		feeLimit += ActivationCost(params)
+	}
+
+	maxFeeLimit := MaxFeeLimit()
+	if feeLimit <= maxFeeLimit {
+		return feeLimit, nil
+	}
+	// the energy burned without staked energy must be covered at least
+	if need > maxFeeLimit {
+		return 0, fmt.Errorf("%v, need %v but max fee limit %v", FeeLimitTooLow, need, maxFeeLimit)
+	}
+	return maxFeeLimit, nil
+}
+
+// EstimateGasResponse price the resources of the transfer without the staked resources
+func EstimateGasResponse(cli *tronclient.GrpcClient, params *ChainParams, energy, bandwidth int64) (*sphinxproxy.GetEstimateGasResponse, error) {
+	block, err := cli.GetNowBlock()
+	if err != nil {
+		return nil, err
+	}
+
+	fee := energy*params.EnergyFee + bandwidth*params.TransactionFee
+	gasLimit, gasPrice := bandwidth, params.TransactionFee
+	if energy > 0 {
+		gasLimit, gasPrice = energy, params.EnergyFee
+	}
+
+	return &sphinxproxy.GetEstimateGasResponse{
+		GasLimit: fmt.Sprint(gasLimit),
+		GasPrice: fmt.Sprint(gasPrice),
+		Fee:      ct.NewAmountFromAtomic(big.NewInt(fee), ChainUnitExp).String(),
+		BlockNum: uint64(block.GetBlockHeader().GetRawData().GetNumber()),
+	}, nil
+}
+
+// CheckBalance the balance must cover the amount and the fee
+func CheckBalance(balance, amount int64, estimate *FeeEstimate) error {
+	if balance < amount+estimate.Fee {
+		return fmt.Errorf("%v, need %v sun(fee %v) but %v", fundsToLow, amount+estimate.Fee, estimate.Fee, balance)
+	}
+	return nil
+}
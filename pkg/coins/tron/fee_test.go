@@ -0,0 +1,85 @@
+package tron
+
+import (
+	"testing"
+
+	"github.com/Geapefurit/gotron-sdk/pkg/proto/api"
+	"github.com/test-go/testify/assert"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestEnergyUsed(t *testing.T) {
+	raw, err := proto.Marshal(&api.TransactionExtention{Txid: []byte{0x01}})
+	assert.Nil(t, err)
+
+	// the newer node append energy_used to the response
+	raw = protowire.AppendTag(raw, energyUsedField, protowire.VarintType)
+	raw = protowire.AppendVarint(raw, 29_650)
+
+	txExtension := &api.TransactionExtention{}
+	assert.Nil(t, proto.Unmarshal(raw, txExtension))
+
+	energy, ok := energyUsed(txExtension)
+	assert.True(t, ok)
+	assert.Equal(t, int64(29_650), energy)
+
+	_, ok = energyUsed(&api.TransactionExtention{})
+	assert.False(t, ok)
+}
+
+func TestFeeLimit(t *testing.T) {
+	params := &ChainParams{EnergyFee: DefaultEnergyFee, TransactionFee: DefaultTransactionFee}
+
+	feeLimit, err := FeeLimit(30_000, false, params)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(30_000*420*120/100), feeLimit)
+
+	feeLimit, err = FeeLimit(200_000, false, params)
+	assert.Nil(t, err)
+	assert.Equal(t, DefaultMaxFeeLimit, feeLimit)
+
+	_, err = FeeLimit(300_000, false, params)
+	assert.True(t, TxFailErr(err))
+}
+
+func TestEstimateFeeInactiveRecipient(t *testing.T) {
+	params := &ChainParams{
+		EnergyFee:        DefaultEnergyFee,
+		TransactionFee:   DefaultTransactionFee,
+		CreateAccountFee: DefaultCreateAccountFee,
+		NewAccountFee:    DefaultNewAccountFee,
+	}
+	const (
+		energy    = int64(30_000)
+		bandwidth = int64(345)
+	)
+	resource := &api.AccountResourceMessage{FreeNetLimit: 600}
+
+	active, err := estimateFee(resource, energy, bandwidth, false, params)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), active.ActivationFee)
+	assert.Equal(t, int64(0), active.BandwidthFee)
+	assert.Equal(t, energy*DefaultEnergyFee, active.Fee)
+	assert.Equal(t, energy*DefaultEnergyFee*feeLimitTolerance/100, active.FeeLimit)
+
+	// the free bandwidth is not used by activating, the fee limit covers the activation
+	inactive, err := estimateFee(resource, energy, bandwidth, true, params)
+	assert.Nil(t, err)
+	assert.Equal(t, DefaultNewAccountFee, inactive.ActivationFee)
+	assert.Equal(t, DefaultCreateAccountFee, inactive.BandwidthFee)
+	assert.Equal(t, active.Fee+ActivationCost(params), inactive.Fee)
+	assert.Equal(t, active.FeeLimit+ActivationCost(params), inactive.FeeLimit)
+
+	// the balance which covers the active recipient is not enough
+	assert.Nil(t, CheckBalance(active.Fee, 0, active))
+	err = CheckBalance(active.Fee, 0, inactive)
+	assert.NotNil(t, err)
+	assert.True(t, TxFailErr(err))
+
+	// the energy fits the max fee limit but the activation not
+	_, err = FeeLimit(DefaultMaxFeeLimit/DefaultEnergyFee, false, params)
+	assert.Nil(t, err)
+	_, err = FeeLimit(DefaultMaxFeeLimit/DefaultEnergyFee, true, params)
+	assert.True(t, TxFailErr(err))
+}
@@ -5,12 +5,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/big"
 	"strings"
 
 	"github.com/NpoolPlatform/go-service-framework/pkg/logger"
 
 	tronclient "github.com/Geapefurit/gotron-sdk/pkg/client"
 	"github.com/NpoolPlatform/message/npool/sphinxplugin"
+	"github.com/NpoolPlatform/message/npool/sphinxproxy"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/register"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/tron"
@@ -44,13 +46,18 @@ func init() {
 		register.OpSyncTx,
 		SyncTxState,
 	)
+	register.RegisteTokenHandler(
+		coins.Tron,
+		register.OpEstimateGas,
+		EstimateGas,
+	)
 
-	err := register.RegisteAbortFuncErr(sphinxplugin.CoinType_CoinTypetron, tron.TxFailErr)
+	err := register.RegisteErrorClassifier(sphinxplugin.CoinType_CoinTypetron, tron.ClassifyErr)
 	if err != nil {
 		panic(err)
 	}
 
-	err = register.RegisteAbortFuncErr(sphinxplugin.CoinType_CoinTypettron, tron.TxFailErr)
+	err = register.RegisteErrorClassifier(sphinxplugin.CoinType_CoinTypettron, tron.ClassifyErr)
 	if err != nil {
 		panic(err)
 	}
@@ -79,6 +86,7 @@ func WalletBalance(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (
 	err = client.WithClient(func(cli *tronclient.GrpcClient) (bool, error) {
 		acc, err := cli.GetAccount(wbReq.Address)
 		if err != nil && strings.Contains(err.Error(), tron.AddressNotActive) {
+			logger.Sugar().Infow("WalletBalance", "Address", wbReq.Address, "Activated", false)
 			bl = tron.EmptyTRX
 			return false, nil
 		}
@@ -95,13 +103,35 @@ func WalletBalance(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (
 		return in, err
 	}
 
-	wbResp := &ct.WalletBalanceResponse{}
-	f := tron.TRXToBigFloat(bl)
+	wbResp := ct.NewWalletBalanceResponse(ct.NewAmountFromAtomic(big.NewInt(bl), tokenInfo.Decimal))
+	return json.Marshal(wbResp)
+}
 
-	wbResp.Balance, _ = f.Float64()
-	wbResp.BalanceStr = f.Text('f', tron.TRXACCURACY)
+func EstimateGas(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []byte, err error) {
+	esGasReq := &sphinxproxy.GetEstimateGasRequest{}
+	err = json.Unmarshal(in, esGasReq)
+	if err != nil {
+		return nil, err
+	}
 
-	return json.Marshal(wbResp)
+	client := tron.Client()
+	var esGasResp *sphinxproxy.GetEstimateGasResponse
+	err = client.WithClient(func(cli *tronclient.GrpcClient) (bool, error) {
+		params, err := tron.GetChainParams(ctx, cli)
+		if err != nil {
+			return true, err
+		}
+
+		esGasResp, err = tron.EstimateGasResponse(cli, params, 0, tron.TransferBandwidth)
+		if err != nil {
+			return true, err
+		}
+		return false, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(esGasResp)
 }
 
 func BuildTransaciton(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []byte, err error) {
@@ -120,21 +150,44 @@ func BuildTransaciton(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo
 		return in, fmt.Errorf("%v,%v", tron.AddressInvalid, err)
 	}
 
+	_amount, err := baseInfo.GetAmount(tokenInfo.Decimal)
+	if err != nil {
+		return in, err
+	}
+	if !_amount.BigInt().IsInt64() {
+		return in, ct.ErrAmountInvalid
+	}
+
 	from := baseInfo.From
 	to := baseInfo.To
-	amount := tron.TRXToInt(baseInfo.Value)
+	amount := _amount.BigInt().Int64()
 
 	client := tron.Client()
 
-	var txExtension *api.TransactionExtention
+	var (
+		txExtension *api.TransactionExtention
+		estimate    *tron.FeeEstimate
+		activated   bool
+	)
 	err = client.WithClient(func(cli *tronclient.GrpcClient) (bool, error) {
-		_, err := cli.GetAccount(from)
+		acc, err := cli.GetAccount(from)
+		if tron.TxFailErr(err) {
+			return false, err
+		}
 		if err != nil {
 			return true, err
 		}
-		if tron.TxFailErr(err) {
-			return false, err
+
+		params, err := tron.GetChainParams(ctx, cli)
+		if err != nil {
+			return true, err
+		}
+
+		activated, err = tron.IsActivated(cli, to)
+		if err != nil {
+			return true, err
 		}
+
 		txExtension, err = cli.Transfer(from, to, amount)
 		if err != nil {
 			return true, err
@@ -142,7 +195,20 @@ func BuildTransaciton(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo
 		if txExtension == nil {
 			return false, errors.New(tron.BuildTransactionFailed)
 		}
-		return false, err
+		if err := tron.SetExpiration(cli, txExtension); err != nil {
+			return false, err
+		}
+
+		bandwidth, err := tron.EstimateBandwidth(txExtension)
+		if err != nil {
+			return false, err
+		}
+
+		estimate, err = tron.EstimateFee(cli, from, 0, bandwidth, !activated, params)
+		if err != nil {
+			return true, err
+		}
+		return false, tron.CheckBalance(acc.GetBalance(), amount, estimate)
 	})
 	if err != nil {
 		return in, err
@@ -151,6 +217,8 @@ func BuildTransaciton(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo
 	signTx := &tron.SignMsgTx{
 		Base:        *baseInfo,
 		TxExtension: txExtension,
+		Fee:         estimate,
+		ToActivated: activated,
 	}
 
 	return json.Marshal(signTx)
@@ -163,14 +231,27 @@ func BroadcastTransaction(ctx context.Context, in []byte, tokenInfo *coins.Token
 		return in, err
 	}
 
-	client := tron.Client()
+	if bReq.TxExtension == nil {
+		return in, errors.New(tron.BuildTransactionFailed)
+	}
+
 	transaction := bReq.TxExtension.Transaction
-	bReq.TxExtension.GetTxid()
+	// the tx id is known before broadcast, the resent transaction is not sent twice
+	txID := common.BytesToHexString(bReq.TxExtension.GetTxid())
+	if tron.IsExpired(transaction) {
+		return rebuildTransaction(ctx, bReq, tokenInfo)
+	}
+
+	client := tron.Client()
 	var result *api.Return
 	err = client.WithClient(func(cli *tronclient.GrpcClient) (bool, error) {
 		result, err = cli.Broadcast(transaction)
 		if err != nil && result != nil && result.GetCode() == api.Return_TRANSACTION_EXPIRATION_ERROR {
-			return false, err
+			return false, nil
+		}
+		// the transaction is sent by the last broadcast
+		if err != nil && result != nil && result.GetCode() == api.Return_DUP_TRANSACTION_ERROR {
+			return false, nil
 		}
 		if err != nil || result == nil {
 			return true, err
@@ -185,8 +266,17 @@ func BroadcastTransaction(ctx context.Context, in []byte, tokenInfo *coins.Token
 		return in, fmt.Errorf("get result failed")
 	}
 
+	if result.GetCode() == api.Return_TRANSACTION_EXPIRATION_ERROR {
+		return rebuildTransaction(ctx, bReq, tokenInfo)
+	}
+
+	if result.GetCode() == api.Return_DUP_TRANSACTION_ERROR {
+		logger.Sugar().Warnw("BroadcastTransaction", "TxID", txID, "Duplicate", true)
+		return json.Marshal(&ct.BroadcastInfo{TxID: txID})
+	}
+
 	if api.Return_SUCCESS == result.Code {
-		bResp := &ct.BroadcastInfo{TxID: common.BytesToHexString(bReq.TxExtension.GetTxid())}
+		bResp := &ct.BroadcastInfo{TxID: txID}
 		if result.Result {
 			return json.Marshal(bResp)
 		}
@@ -199,10 +289,10 @@ func BroadcastTransaction(ctx context.Context, in []byte, tokenInfo *coins.Token
 		api.Return_CONTRACT_EXE_ERROR,
 		// api.Return_BANDWIDTH_ERROR=4,
 		4,
-		api.Return_DUP_TRANSACTION_ERROR,
+		// api.Return_DUP_TRANSACTION_ERROR, sent by the last broadcast
 		api.Return_TAPOS_ERROR,
 		api.Return_TOO_BIG_TRANSACTION_ERROR,
-		api.Return_TRANSACTION_EXPIRATION_ERROR,
+		// api.Return_TRANSACTION_EXPIRATION_ERROR, rebuild it
 		// api.Return_SERVER_BUSY,
 		// api.Return_NO_CONNECTION,
 		// api.Return_NOT_ENOUGH_EFFECTIVE_CONNECTION,
@@ -217,6 +307,54 @@ func BroadcastTransaction(ctx context.Context, in []byte, tokenInfo *coins.Token
 	return in, errors.New(string(result.GetMessage()))
 }
 
+// rebuildTransaction build the expired transaction again by the pre sign handler,
+// the payload is routed back to sign instead of failing the transaction
+func rebuildTransaction(ctx context.Context, bReq *tron.BroadcastRequest, tokenInfo *coins.TokenInfo) (out []byte, err error) {
+	// the transaction may be expired after it is on chain by the last broadcast
+	txID := common.BytesToHexString(bReq.TxExtension.GetTxid())
+	var broadcasted bool
+	err = tron.Client().WithClient(func(cli *tronclient.GrpcClient) (bool, error) {
+		broadcasted, err = tron.Broadcasted(cli, txID)
+		if err != nil {
+			return true, err
+		}
+		return false, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if broadcasted {
+		return json.Marshal(&ct.BroadcastInfo{TxID: txID})
+	}
+
+	// signed by the old version, nothing to rebuild it
+	if bReq.Base == nil {
+		return nil, env.ErrTransactionFail
+	}
+
+	handler, ok := register.TokenHandlers[tokenInfo.TokenType][register.OpPreSign]
+	if !ok {
+		return nil, env.ErrTransactionFail
+	}
+
+	in, err := json.Marshal(bReq.Base)
+	if err != nil {
+		return nil, err
+	}
+	resign, err := handler(ctx, in, tokenInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Sugar().Warnw(
+		"BroadcastTransaction",
+		"TxID", txID,
+		"Expiration", bReq.TxExtension.GetTransaction().GetRawData().GetExpiration(),
+		"Rebuild", true,
+	)
+	return json.Marshal(&ct.BroadcastInfo{Resign: resign})
+}
+
 // done(on chain) => true
 func SyncTxState(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []byte, err error) {
 	syncReq := &ct.SyncRequest{}
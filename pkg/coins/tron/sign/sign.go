@@ -80,7 +80,10 @@ func SignTronMSG(ctx context.Context, s3Strore string, in []byte) (out []byte, e
 	}
 
 	transaction.Signature = append(transaction.Signature, signature)
-	signedMsg := &tron.BroadcastRequest{TxExtension: signMsgTx.TxExtension}
+	signedMsg := &tron.BroadcastRequest{
+		TxExtension: signMsgTx.TxExtension,
+		Base:        &signMsgTx.Base,
+	}
 	return json.Marshal(signedMsg)
 }
 
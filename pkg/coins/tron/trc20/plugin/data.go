@@ -0,0 +1,41 @@
+package trc20
+
+import (
+	v1 "github.com/NpoolPlatform/message/npool/basetypes/v1"
+	"github.com/NpoolPlatform/message/npool/sphinxplugin"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/register"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/tron"
+)
+
+func init() {
+	for i := range trc20tokens {
+		// set chain info
+		trc20tokens[i].ChainType = tron.ChainType
+		trc20tokens[i].ChainNativeUnit = tron.ChainNativeUnit
+		trc20tokens[i].ChainAtomicUnit = tron.ChainAtomicUnit
+		trc20tokens[i].ChainUnitExp = tron.ChainUnitExp
+		trc20tokens[i].GasType = v1.GasType_DynamicGas
+		trc20tokens[i].ChainID = tron.ChainID
+		trc20tokens[i].ChainNickname = tron.ChainType.String()
+		trc20tokens[i].ChainNativeCoinName = tron.ChainNativeCoinName
+
+		trc20tokens[i].TokenType = coins.Trc20
+		trc20tokens[i].Net = coins.CoinNetMain
+		trc20tokens[i].Waight = 1
+		trc20tokens[i].Contract = trc20tokens[i].OfficialContract
+		trc20tokens[i].CoinType = sphinxplugin.CoinType_CoinTypetron
+		trc20tokens[i].Name = coins.GenerateName(&trc20tokens[i])
+		register.RegisteTokenInfo(&trc20tokens[i])
+	}
+}
+
+// usdttrc20 is registered in tron base with its own coin type
+var trc20tokens = []coins.TokenInfo{
+	{OfficialName: "USD Coin", Decimal: 6, Unit: "USDC", OfficialContract: "TEkxiTehnzSmSe2XqrBj4w32RUN966rdz8"},
+	{OfficialName: "Decentralized USD", Decimal: 18, Unit: "USDD", OfficialContract: "TPYmHEhy5n8TCEfYGqW2rPxsghSfzghPDn"},
+	{OfficialName: "TrueUSD", Decimal: 18, Unit: "TUSD", OfficialContract: "TUpMhErZL2fhh4sVNULAbNKLokS4GjC1F4"},
+	{OfficialName: "BitTorrent", Decimal: 18, Unit: "BTT", OfficialContract: "TAFjULxiVgT4qWk6UZwjqwZXTSaGaqnVp4"},
+	{OfficialName: "JUST GOV", Decimal: 18, Unit: "JST", OfficialContract: "TCFLL5dx5ZJdKnWuesXxi1VPwjLVmWZZy9"},
+	{OfficialName: "WINK", Decimal: 6, Unit: "WIN", OfficialContract: "TLa2f6VPqDgRE67v1736s7bJ8Ray5wYjU7"},
+}
@@ -6,9 +6,12 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/NpoolPlatform/go-service-framework/pkg/logger"
+
 	tronclient "github.com/Geapefurit/gotron-sdk/pkg/client"
 	"github.com/Geapefurit/gotron-sdk/pkg/proto/api"
 	"github.com/NpoolPlatform/message/npool/sphinxplugin"
+	"github.com/NpoolPlatform/message/npool/sphinxproxy"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/register"
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/tron"
@@ -39,15 +42,27 @@ func init() {
 		register.OpSyncTx,
 		tron_plugin.SyncTxState,
 	)
+	register.RegisteTokenHandler(
+		coins.Trc20,
+		register.OpEstimateGas,
+		EstimateGas,
+	)
 
-	err := register.RegisteAbortFuncErr(sphinxplugin.CoinType_CoinTypeusdttrc20, tron.TxFailErr)
-	if err != nil {
-		panic(err)
-	}
-
-	err = register.RegisteAbortFuncErr(sphinxplugin.CoinType_CoinTypetusdttrc20, tron.TxFailErr)
-	if err != nil {
-		panic(err)
+	// the trc20 tokens of data.go use the coin type of tron
+	for _, coinType := range []sphinxplugin.CoinType{
+		sphinxplugin.CoinType_CoinTypeusdttrc20,
+		sphinxplugin.CoinType_CoinTypetusdttrc20,
+		sphinxplugin.CoinType_CoinTypetron,
+		sphinxplugin.CoinType_CoinTypettron,
+	} {
+		// the classifier of tron is registered by the tron plugin
+		if _, ok := register.ErrorClassifiers[coinType]; ok {
+			continue
+		}
+		err := register.RegisteErrorClassifier(coinType, tron.ClassifyErr)
+		if err != nil {
+			panic(err)
+		}
 	}
 }
 
@@ -58,18 +73,9 @@ func WalletBalance(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (
 		return nil, err
 	}
 
-	v, ok := env.LookupEnv(env.ENVCOINNET)
-	if !ok {
-		return nil, env.ErrEVNCoinNet
-	}
-	if !coins.CheckSupportNet(v) {
-		return nil, env.ErrEVNCoinNetValue
-	}
-
-	contract := tron.USDTContract(v)
-	err = tron.ValidAddress(contract)
+	contract, err := tron.TRC20Contract(tokenInfo)
 	if err != nil {
-		return nil, fmt.Errorf("contract %v, %v, %v", contract, tron.AddressInvalid, err)
+		return nil, err
 	}
 
 	bl := tron.EmptyTRC20
@@ -81,6 +87,7 @@ func WalletBalance(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (
 	err = client.WithClient(func(c *tronclient.GrpcClient) (bool, error) {
 		bl, err = c.TRC20ContractBalance(wbReq.Address, contract)
 		if err != nil && strings.Contains(err.Error(), tron.AddressNotActive) {
+			logger.Sugar().Infow("WalletBalance", "Address", wbReq.Address, "Activated", false)
 			bl = tron.EmptyTRC20
 			return false, nil
 		}
@@ -93,17 +100,50 @@ func WalletBalance(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (
 		return nil, err
 	}
 
-	f := tron.TRC20ToBigFloat(bl)
-	wbResp := &ct.WalletBalanceResponse{}
-
-	wbResp.Balance, _ = f.Float64()
-	wbResp.BalanceStr = f.Text('f', tron.TRC20ACCURACY)
-
+	wbResp := ct.NewWalletBalanceResponse(ct.NewAmountFromAtomic(bl, tokenInfo.Decimal))
 	out, err = json.Marshal(wbResp)
 
 	return out, err
 }
 
+func EstimateGas(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []byte, err error) {
+	esGasReq := &sphinxproxy.GetEstimateGasRequest{}
+	err = json.Unmarshal(in, esGasReq)
+	if err != nil {
+		return nil, err
+	}
+
+	contract, err := tron.TRC20Contract(tokenInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	client := tron.Client()
+	var esGasResp *sphinxproxy.GetEstimateGasResponse
+	err = client.WithClient(func(c *tronclient.GrpcClient) (bool, error) {
+		params, err := tron.GetChainParams(ctx, c)
+		if err != nil {
+			return true, err
+		}
+
+		// mock transfer zero token from the zero address
+		energy, err := tron.EstimateTRC20Energy(c, "", contract, contract, tron.EmptyTRC20)
+		if err != nil {
+			return true, err
+		}
+
+		esGasResp, err = tron.EstimateGasResponse(c, params, energy, tron.TRC20Bandwidth)
+		if err != nil {
+			return true, err
+		}
+		return false, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(esGasResp)
+}
+
 func BuildTransaciton(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) (out []byte, err error) {
 	baseInfo := &ct.BaseInfo{}
 	err = json.Unmarshal(in, baseInfo)
@@ -125,23 +165,76 @@ func BuildTransaciton(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo
 		return nil, fmt.Errorf("%v,%v", tron.AddressInvalid, err)
 	}
 
-	contract := tron.USDTContract(baseInfo.ENV)
-	err = tron.ValidAddress(contract)
+	contract, err := tron.TRC20Contract(tokenInfo)
 	if err != nil {
-		return nil, fmt.Errorf("contract %v, %v, %v", contract, tron.AddressInvalid, err)
+		return nil, err
 	}
 
-	var txExtension *api.TransactionExtention
+	amount, err := baseInfo.GetAmount(tokenInfo.Decimal)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		txExtension *api.TransactionExtention
+		estimate    *tron.FeeEstimate
+		activated   bool
+	)
 	client := tron.Client()
 	err = client.WithClient(func(c *tronclient.GrpcClient) (bool, error) {
+		acc, err := c.GetAccount(baseInfo.From)
+		if tron.TxFailErr(err) {
+			return false, err
+		}
+		if err != nil {
+			return true, err
+		}
+
+		params, err := tron.GetChainParams(ctx, c)
+		if err != nil {
+			return true, err
+		}
+
+		// the cost of the inactive recipient is covered by the fee limit and the balance
+		activated, err = tron.IsActivated(c, baseInfo.To)
+		if err != nil {
+			return true, err
+		}
+
+		energy, err := tron.EstimateTRC20Energy(c, baseInfo.From, baseInfo.To, contract, amount.BigInt())
+		if err != nil {
+			return true, err
+		}
+
+		feeLimit, err := tron.FeeLimit(energy, !activated, params)
+		if err != nil {
+			return false, err
+		}
+
 		txExtension, err = c.TRC20Send(
 			baseInfo.From,
 			baseInfo.To,
 			contract,
-			tron.TRC20ToBigInt(baseInfo.Value),
-			tron.TRC20FeeLimit,
+			amount.BigInt(),
+			feeLimit,
 		)
-		return false, err
+		if err != nil {
+			return false, err
+		}
+		if err := tron.SetExpiration(c, txExtension); err != nil {
+			return false, err
+		}
+
+		bandwidth, err := tron.EstimateBandwidth(txExtension)
+		if err != nil {
+			return false, err
+		}
+
+		estimate, err = tron.EstimateFee(c, baseInfo.From, energy, bandwidth, !activated, params)
+		if err != nil {
+			return true, err
+		}
+		return false, tron.CheckBalance(acc.GetBalance(), 0, estimate)
 	})
 	if err != nil {
 		return nil, err
@@ -149,6 +242,8 @@ func BuildTransaciton(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo
 	signTx := &tron.SignMsgTx{
 		Base:        *baseInfo,
 		TxExtension: txExtension,
+		Fee:         estimate,
+		ToActivated: activated,
 	}
 
 	return json.Marshal(signTx)
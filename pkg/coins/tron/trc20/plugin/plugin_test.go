@@ -0,0 +1,33 @@
+package trc20
+
+import (
+	"testing"
+
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins/register"
+	"github.com/test-go/testify/assert"
+)
+
+func TestTokenRegister(t *testing.T) {
+	trc20s := 0
+	for coinType, tokenInfos := range register.TokenInfoMap {
+		for _, tokenInfo := range tokenInfos {
+			if tokenInfo.TokenType != coins.Trc20 {
+				continue
+			}
+			trc20s++
+
+			_, ok := register.ErrorClassifiers[coinType]
+			assert.True(t, ok, tokenInfo.Name)
+
+			// the test token get its own contract by the net handler
+			if tokenInfo.Net == coins.CoinNetTest {
+				assert.Equal(t, "", tokenInfo.Contract, tokenInfo.Name)
+				assert.True(t, tokenInfo.DisableRegiste, tokenInfo.Name)
+				_, ok = register.TokenNetHandlers[coinType]
+				assert.True(t, ok, tokenInfo.Name)
+			}
+		}
+	}
+	assert.Equal(t, 2*(len(trc20tokens)+1), trc20s)
+}
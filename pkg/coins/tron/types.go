@@ -8,8 +8,14 @@ import (
 type SignMsgTx struct {
 	Base        ct.BaseInfo               `json:"base"`
 	TxExtension *api.TransactionExtention `json:"tx_extension"`
+	// the estimated resources and fee
+	Fee *FeeEstimate `json:"fee,omitempty"`
+	// the recipient is activated, the trx transfer to the inactive one activates it
+	ToActivated bool `json:"to_activated"`
 }
 
 type BroadcastRequest struct {
 	TxExtension *api.TransactionExtention `json:"tx_extension"`
+	// the transaction is built again by it when expired
+	Base *ct.BaseInfo `json:"base,omitempty"`
 }
@@ -0,0 +1,27 @@
+package utxo
+
+import (
+	"strings"
+)
+
+// the node reject the transaction which is sent by the last broadcast
+var alreadyBroadcastedErrMsg = []string{
+	`txn-already-in-mempool`,
+	`txn-already-known`,
+	`already in block chain`,
+	`outputs already in utxo set`,
+}
+
+// AlreadyBroadcastedErr the resent transaction is already in the mempool or on chain
+func AlreadyBroadcastedErr(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	for _, v := range alreadyBroadcastedErrMsg {
+		if strings.Contains(strings.ToLower(err.Error()), v) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,16 @@
+package utxo
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/test-go/testify/assert"
+)
+
+func TestAlreadyBroadcastedErr(t *testing.T) {
+	assert.False(t, AlreadyBroadcastedErr(nil))
+	assert.True(t, AlreadyBroadcastedErr(errors.New("-26: txn-already-in-mempool")))
+	assert.True(t, AlreadyBroadcastedErr(errors.New("-27: Transaction already in block chain")))
+	assert.True(t, AlreadyBroadcastedErr(errors.New("-27: Transaction outputs already in utxo set")))
+	assert.False(t, AlreadyBroadcastedErr(errors.New("-26: txn-mempool-conflict")))
+}
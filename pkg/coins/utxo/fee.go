@@ -0,0 +1,116 @@
+package utxo
+
+import (
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+)
+
+const (
+	// DefaultConfTarget the blocks expected to be confirmed for estimatesmartfee
+	DefaultConfTarget = 6
+	// MinRelayFeeRate satoshi per kvB
+	MinRelayFeeRate btcutil.Amount = 1000
+
+	// vsize of the p2pkh transaction
+	P2PKHInputSize  = 148
+	P2PKHOutputSize = 34
+	TxOverheadSize  = 10
+	// P2PKHDustLimit the output less than it is rejected by the node
+	P2PKHDustLimit btcutil.Amount = 546
+
+	// vsize of the p2wpkh transaction, the witness is discounted
+	P2WPKHInputSize                = 68
+	SegwitOverhead                 = 11
+	P2WPKHDustLimit btcutil.Amount = 294
+)
+
+const (
+	// the output is value(8 bytes) + script length(1 byte) + script
+	outputValueSize  = 8
+	outputScriptSize = 1
+)
+
+// Params is the fee parameters of the coin selection
+type Params struct {
+	// FeeRate satoshi per kvB
+	FeeRate btcutil.Amount
+	// vsize
+	InputSize  int64
+	OutputSize int64
+	Overhead   int64
+	// DustLimit the change less than it is given to the fee
+	DustLimit btcutil.Amount
+}
+
+// NewP2PKHParams the params of the p2pkh inputs and outputs
+func NewP2PKHParams(feeRate btcutil.Amount) *Params {
+	return &Params{
+		FeeRate:    feeRate,
+		InputSize:  P2PKHInputSize,
+		OutputSize: P2PKHOutputSize,
+		Overhead:   TxOverheadSize,
+		DustLimit:  P2PKHDustLimit,
+	}
+}
+
+// NewScriptParams the params of spending the fromScript utxos and paying to the toScript,
+// the change is paid to the fromScript
+func NewScriptParams(feeRate btcutil.Amount, fromScript, toScript []byte) *Params {
+	params := NewP2PKHParams(feeRate)
+	if txscript.GetScriptClass(fromScript) == txscript.WitnessV0PubKeyHashTy {
+		params.InputSize = P2WPKHInputSize
+		params.Overhead = SegwitOverhead
+		params.DustLimit = P2WPKHDustLimit
+	}
+
+	params.OutputSize = outputSize(fromScript)
+	if size := outputSize(toScript); size > params.OutputSize {
+		params.OutputSize = size
+	}
+	return params
+}
+
+func outputSize(pkScript []byte) int64 {
+	return outputValueSize + outputScriptSize + int64(len(pkScript))
+}
+
+func (p *Params) feeOf(vsize int64) btcutil.Amount {
+	// round up
+	return (p.FeeRate*btcutil.Amount(vsize) + 999) / 1000
+}
+
+// VSize the virtual size of the transaction
+func (p *Params) VSize(inputs, outputs int) int64 {
+	return p.Overhead + int64(inputs)*p.InputSize + int64(outputs)*p.OutputSize
+}
+
+// Fee the fee of the transaction
+func (p *Params) Fee(inputs, outputs int) btcutil.Amount {
+	return p.feeOf(p.VSize(inputs, outputs))
+}
+
+// EffectiveValue the amount of the utxo minus the fee to spend it
+func (p *Params) EffectiveValue(u UTXO) btcutil.Amount {
+	return u.Amount - p.feeOf(p.InputSize)
+}
+
+// costOfChange the fee to create the change output and spend it later
+func (p *Params) costOfChange() btcutil.Amount {
+	return p.feeOf(p.OutputSize) + p.feeOf(p.InputSize)
+}
+
+// FeeRateFromEstimate convert the estimatesmartfee result(BTC/kvB) to satoshi per kvB,
+// use the fallback when the node has no enough data to estimate
+func FeeRateFromEstimate(result *btcjson.EstimateSmartFeeResult, fallback btcutil.Amount) btcutil.Amount {
+	feeRate := fallback
+	if result != nil && result.FeeRate != nil && len(result.Errors) == 0 {
+		if rate, err := btcutil.NewAmount(*result.FeeRate); err == nil {
+			feeRate = rate
+		}
+	}
+	if feeRate < MinRelayFeeRate {
+		feeRate = MinRelayFeeRate
+	}
+	return feeRate
+}
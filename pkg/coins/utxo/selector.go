@@ -0,0 +1,189 @@
+package utxo
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/config"
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcutil"
+)
+
+const (
+	// StrategyAuto try branch-and-bound first, then knapsack, finally largest-first
+	StrategyAuto         = "auto"
+	StrategyBranchBound  = "bnb"
+	StrategyKnapsack     = "knapsack"
+	StrategyLargestFirst = "largest-first"
+)
+
+var (
+	ErrInsufficientFunds = errors.New("insufficient balance")
+	ErrStrategyNotFound  = errors.New("utxo selection strategy not found")
+	// ErrNoSolution the strategy can not find the inputs, try the next strategy
+	ErrNoSolution = errors.New("utxo selection no solution")
+)
+
+// UTXO is the spendable output
+type UTXO struct {
+	TxID     string
+	Vout     uint32
+	Amount   btcutil.Amount
+	PkScript []byte
+}
+
+// FromListUnspent convert the list unspent result to utxos
+func FromListUnspent(results []btcjson.ListUnspentResult) ([]UTXO, error) {
+	utxos := make([]UTXO, 0, len(results))
+	for _, result := range results {
+		amount, err := btcutil.NewAmount(result.Amount)
+		if err != nil {
+			return nil, err
+		}
+		pkScript, err := hex.DecodeString(result.ScriptPubKey)
+		if err != nil {
+			return nil, err
+		}
+		utxos = append(utxos, UTXO{
+			TxID:     result.TxID,
+			Vout:     result.Vout,
+			Amount:   amount,
+			PkScript: pkScript,
+		})
+	}
+	return utxos, nil
+}
+
+// Selection is the result of the coin selection, change is zero when the
+// transaction has no change output
+type Selection struct {
+	Inputs []UTXO
+	Fee    btcutil.Amount
+	Change btcutil.Amount
+}
+
+func (s *Selection) String() string {
+	return fmt.Sprintf("inputs: %v, fee: %v, change: %v", len(s.Inputs), s.Fee, s.Change)
+}
+
+// Selector select the inputs which cover the amount and the fee
+type Selector interface {
+	Select(utxos []UTXO, amount btcutil.Amount, params *Params) (*Selection, error)
+}
+
+var (
+	selectorsLock sync.RWMutex
+	selectors     = map[string]Selector{}
+)
+
+// Register add the selection strategy
+func Register(name string, selector Selector) {
+	selectorsLock.Lock()
+	defer selectorsLock.Unlock()
+	selectors[name] = selector
+}
+
+func GetSelector(name string) (Selector, error) {
+	selectorsLock.RLock()
+	defer selectorsLock.RUnlock()
+	selector, ok := selectors[name]
+	if !ok {
+		return nil, fmt.Errorf("%v: %v", ErrStrategyNotFound, name)
+	}
+	return selector, nil
+}
+
+func init() {
+	Register(StrategyBranchBound, branchBound{})
+	Register(StrategyKnapsack, knapsack{})
+	Register(StrategyLargestFirst, largestFirst{})
+	Register(StrategyAuto, auto{
+		strategies: []Selector{branchBound{}, knapsack{}, largestFirst{}},
+	})
+}
+
+// Strategy the configured selection strategy
+func Strategy() string {
+	if envInfo := config.GetENV(); envInfo != nil && envInfo.UTXOStrategy != "" {
+		return envInfo.UTXOStrategy
+	}
+	return StrategyAuto
+}
+
+// Select select the inputs by the strategy
+func Select(strategy string, utxos []UTXO, amount btcutil.Amount, params *Params) (*Selection, error) {
+	if strategy == "" {
+		strategy = StrategyAuto
+	}
+	selector, err := GetSelector(strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	var total btcutil.Amount
+	for _, u := range utxos {
+		total += u.Amount
+	}
+	if total < amount+params.Fee(0, 1) {
+		return nil, ErrInsufficientFunds
+	}
+
+	selection, err := selector.Select(utxos, amount, params)
+	if errors.Is(err, ErrNoSolution) {
+		return nil, ErrInsufficientFunds
+	}
+	return selection, err
+}
+
+type auto struct {
+	strategies []Selector
+}
+
+func (a auto) Select(utxos []UTXO, amount btcutil.Amount, params *Params) (*Selection, error) {
+	for _, strategy := range a.strategies {
+		selection, err := strategy.Select(utxos, amount, params)
+		if errors.Is(err, ErrNoSolution) {
+			continue
+		}
+		return selection, err
+	}
+	return nil, ErrNoSolution
+}
+
+// finalize calculate the fee and the change of the inputs, the change less than
+// the dust limit is given to the fee
+func finalize(inputs []UTXO, amount btcutil.Amount, params *Params) (*Selection, error) {
+	var total btcutil.Amount
+	for _, u := range inputs {
+		total += u.Amount
+	}
+
+	feeWithChange := params.Fee(len(inputs), 2)
+	if change := total - amount - feeWithChange; change >= params.DustLimit {
+		return &Selection{Inputs: inputs, Fee: feeWithChange, Change: change}, nil
+	}
+
+	if total >= amount+params.Fee(len(inputs), 1) {
+		return &Selection{Inputs: inputs, Fee: total - amount}, nil
+	}
+
+	return nil, ErrNoSolution
+}
+
+// sortByEffectiveValue sort a copy of the utxos by the effective value desc, the utxos
+// which cost more fee than its amount are dropped
+func sortByEffectiveValue(utxos []UTXO, params *Params) []UTXO {
+	sorted := make([]UTXO, 0, len(utxos))
+	for _, u := range utxos {
+		if params.EffectiveValue(u) > 0 {
+			sorted = append(sorted, u)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Amount > sorted[j].Amount
+	})
+	return sorted
+}
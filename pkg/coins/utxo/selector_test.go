@@ -0,0 +1,53 @@
+package utxo
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/test-go/testify/assert"
+)
+
+func testUTXOs(amounts ...btcutil.Amount) []UTXO {
+	utxos := make([]UTXO, 0, len(amounts))
+	for i, amount := range amounts {
+		utxos = append(utxos, UTXO{TxID: "tx", Vout: uint32(i), Amount: amount})
+	}
+	return utxos
+}
+
+func sum(inputs []UTXO) btcutil.Amount {
+	var total btcutil.Amount
+	for _, u := range inputs {
+		total += u.Amount
+	}
+	return total
+}
+
+func TestSelect(t *testing.T) {
+	params := NewP2PKHParams(10_000)
+	utxos := testUTXOs(100_000, 50_000, 30_000, 7_000, 2_000)
+
+	for _, strategy := range []string{StrategyAuto, StrategyBranchBound, StrategyKnapsack, StrategyLargestFirst} {
+		selection, err := Select(strategy, utxos, 60_000, params)
+		if strategy == StrategyBranchBound && err != nil {
+			// no exact match
+			continue
+		}
+		assert.Nil(t, err, strategy)
+		assert.Equal(t, sum(selection.Inputs), 60_000+selection.Fee+selection.Change, strategy)
+		assert.True(t, selection.Fee >= params.Fee(len(selection.Inputs), 1), strategy)
+		if selection.Change > 0 {
+			assert.True(t, selection.Change >= params.DustLimit, strategy)
+		}
+	}
+
+	// 50_000 + 30_000 - 2 inputs fee match the amount without change
+	amount := 80_000 - params.Fee(2, 1)
+	selection, err := Select(StrategyBranchBound, utxos, amount, params)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(selection.Inputs))
+	assert.Equal(t, btcutil.Amount(0), selection.Change)
+
+	_, err = Select(StrategyAuto, utxos, 200_000, params)
+	assert.Equal(t, ErrInsufficientFunds, err)
+}
@@ -0,0 +1,180 @@
+package utxo
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/btcsuite/btcutil"
+)
+
+const (
+	bnbMaxTries        = 100_000
+	knapsackIterations = 1000
+)
+
+// branchBound search the inputs which match the amount exactly, so the transaction
+// needs no change output, the excess less than the cost of change is given to the fee
+type branchBound struct{}
+
+func (branchBound) Select(utxos []UTXO, amount btcutil.Amount, params *Params) (*Selection, error) {
+	sorted := sortByEffectiveValue(utxos, params)
+
+	var (
+		target     = amount + params.Fee(0, 1)
+		upperBound = target + params.costOfChange()
+		remaining  btcutil.Amount
+		values     = make([]btcutil.Amount, len(sorted))
+	)
+	for i, u := range sorted {
+		values[i] = params.EffectiveValue(u)
+		remaining += values[i]
+	}
+	if remaining < target {
+		return nil, ErrNoSolution
+	}
+
+	var (
+		selected  = make([]bool, len(sorted))
+		best      []bool
+		bestWaste btcutil.Amount = -1
+		tries     int
+		search    func(depth int, current, remaining btcutil.Amount)
+	)
+
+	// depth first search, include the utxo first then exclude it
+	search = func(depth int, current, remaining btcutil.Amount) {
+		if tries >= bnbMaxTries || bestWaste == 0 {
+			return
+		}
+		tries++
+
+		if current > upperBound || current+remaining < target {
+			return
+		}
+		if current >= target {
+			if waste := current - target; bestWaste < 0 || waste < bestWaste {
+				bestWaste = waste
+				best = append([]bool(nil), selected...)
+			}
+			return
+		}
+		if depth >= len(values) {
+			return
+		}
+
+		remaining -= values[depth]
+		selected[depth] = true
+		search(depth+1, current+values[depth], remaining)
+		selected[depth] = false
+		search(depth+1, current, remaining)
+	}
+	search(0, 0, remaining)
+
+	if best == nil {
+		return nil, ErrNoSolution
+	}
+
+	inputs := make([]UTXO, 0)
+	for i, ok := range best {
+		if ok {
+			inputs = append(inputs, sorted[i])
+		}
+	}
+	return finalize(inputs, amount, params)
+}
+
+// knapsack approximate the subset which exceed the amount and the change least,
+// it is the stochastic approximation of bitcoin core
+type knapsack struct{}
+
+func (knapsack) Select(utxos []UTXO, amount btcutil.Amount, params *Params) (*Selection, error) {
+	sorted := sortByEffectiveValue(utxos, params)
+
+	var (
+		target = amount + params.Fee(0, 2) + params.DustLimit
+		// the smallest single utxo which cover the target
+		lowestLarger *UTXO
+		smaller      = make([]UTXO, 0, len(sorted))
+		values       = make([]btcutil.Amount, 0, len(sorted))
+		total        btcutil.Amount
+	)
+	for i, u := range sorted {
+		value := params.EffectiveValue(u)
+		if value >= target {
+			lowestLarger = &sorted[i]
+			continue
+		}
+		smaller = append(smaller, u)
+		values = append(values, value)
+		total += value
+	}
+
+	if total < target {
+		if lowestLarger == nil {
+			return nil, ErrNoSolution
+		}
+		return finalize([]UTXO{*lowestLarger}, amount, params)
+	}
+
+	r := rand.New(rand.NewSource(time.Now().UnixNano())) //nolint
+	best := make([]bool, len(smaller))
+	for i := range best {
+		best[i] = true
+	}
+	bestValue := total
+
+	included := make([]bool, len(smaller))
+	for rep := 0; rep < knapsackIterations && bestValue != target; rep++ {
+		for i := range included {
+			included[i] = false
+		}
+		var current btcutil.Amount
+		reached := false
+		for pass := 0; pass < 2 && !reached; pass++ {
+			for i := range smaller {
+				// the first pass pick randomly, the second pass pick the rest
+				if (pass == 0 && r.Intn(2) == 0) || (pass == 1 && !included[i]) {
+					current += values[i]
+					included[i] = true
+					if current >= target {
+						reached = true
+						if current < bestValue {
+							bestValue = current
+							copy(best, included)
+						}
+						current -= values[i]
+						included[i] = false
+					}
+				}
+			}
+		}
+	}
+
+	if lowestLarger != nil && params.EffectiveValue(*lowestLarger) <= bestValue {
+		return finalize([]UTXO{*lowestLarger}, amount, params)
+	}
+
+	inputs := make([]UTXO, 0)
+	for i, ok := range best {
+		if ok {
+			inputs = append(inputs, smaller[i])
+		}
+	}
+	return finalize(inputs, amount, params)
+}
+
+// largestFirst pick the largest utxo until the amount and the fee is covered
+type largestFirst struct{}
+
+func (largestFirst) Select(utxos []UTXO, amount btcutil.Amount, params *Params) (*Selection, error) {
+	sorted := sortByEffectiveValue(utxos, params)
+
+	inputs := make([]UTXO, 0)
+	for _, u := range sorted {
+		inputs = append(inputs, u)
+		if selection, err := finalize(inputs, amount, params); err == nil {
+			return selection, nil
+		}
+	}
+	return nil, ErrNoSolution
+}
@@ -74,6 +74,42 @@ type ENVInfo struct {
 	WanIP            string
 	Position         string
 	BuildChainServer string
+	// eip-1559 fee policy of the evm chains
+	EthBaseFeeMultiplier float64
+	EthMaxTipGwei        float64
+	EthMaxFeeGwei        float64
+	// stuck policy of the evm chains
+	EvmStuckBlocks     uint64
+	EvmStuckTimeout    int64
+	EvmFeeBumpPercent  int64
+	EvmMaxReplaceTimes int
+	// utxo selection strategy of btc and depinc
+	UTXOStrategy string
+	// address type of the new bitcoin account
+	BTCAddressType string
+	// upper limit of the filecoin message fee(FIL)
+	FilMaxFee float64
+	// upper limit of the trc20 fee limit(TRX)
+	TronMaxFeeLimit float64
+	// expiration window of the tron transaction from built(second)
+	TronTxExpiration int
+	// priority fee policy of solana, the percentile of the recent fees and the
+	// cap of the compute unit price(micro-lamports)
+	SolPriorityFeePercentile int
+	SolMaxComputeUnitPrice   uint64
+	// version of the built solana transaction and the lookup tables of the v0 one
+	SolTxVersion    string
+	SolLookupTables string
+	// the transactions handled concurrently by the nonce, broadcast and sync task
+	NonceParallelism     int
+	BroadcastParallelism int
+	SyncParallelism      int
+	// retry budget of the failed transaction, the max attempts and the max age(second)
+	// from the first failed attempt, 0 is unlimited
+	RetryMaxAttempts int
+	RetryMaxAge      int64
+	// file of the local journal of the in-flight transactions, empty is disabled
+	JournalPath string
 }
 
 func SetENV(info *ENVInfo) {
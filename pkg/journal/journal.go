@@ -0,0 +1,337 @@
+package journal
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/NpoolPlatform/message/npool/sphinxproxy"
+)
+
+// ErrJournalClosed ..
+var ErrJournalClosed = errors.New("journal closed")
+
+const (
+	// the record which is not updated in it is dropped when the journal is compacted
+	recordRetention = 7 * 24 * time.Hour
+	// the journal is compacted when the appended lines is more than it and twice the records
+	compactThreshold = 10_000
+)
+
+// Transition one state transition of the transaction
+type Transition struct {
+	State     sphinxproxy.TransactionState `json:"state"`
+	NextState sphinxproxy.TransactionState `json:"nextState"`
+	At        int64                        `json:"at"`
+}
+
+// Record the journaled transaction, the last update is written before it is sent to
+// the proxy and it is marked synced after the proxy accept it
+type Record struct {
+	TransactionID string `json:"transactionID"`
+	Name          string `json:"name"`
+	// the last update, the input is the digest of the payload it is handled from
+	State     sphinxproxy.TransactionState `json:"state"`
+	NextState sphinxproxy.TransactionState `json:"nextState"`
+	Input     string                       `json:"input"`
+	Payload   []byte                       `json:"payload,omitempty"`
+	CID       string                       `json:"cid,omitempty"`
+	ExitCode  int64                        `json:"exitCode,omitempty"`
+	Synced    bool                         `json:"synced"`
+	// the pre sign payload, the signed payload and the tx hash of the transaction
+	PreSign []byte `json:"preSign,omitempty"`
+	Signed  []byte `json:"signed,omitempty"`
+	TxID    string `json:"txID,omitempty"`
+
+	History   []Transition `json:"history"`
+	UpdatedAt int64        `json:"updatedAt"`
+}
+
+// Request the update request of the last transition
+func (r *Record) Request() *sphinxproxy.UpdateTransactionRequest {
+	return &sphinxproxy.UpdateTransactionRequest{
+		TransactionID:        r.TransactionID,
+		TransactionState:     r.State,
+		NextTransactionState: r.NextState,
+		ExitCode:             r.ExitCode,
+		CID:                  r.CID,
+		Payload:              r.Payload,
+	}
+}
+
+func (r *Record) copy() *Record {
+	record := *r
+	record.History = append([]Transition(nil), r.History...)
+	return &record
+}
+
+// Digest the digest of the payload the transition is handled from
+func Digest(payload []byte) string {
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// Journal the append only journal of the in-flight transactions, every line is
+// the last snapshot of one transaction, the nil journal is disabled
+type Journal struct {
+	mu       sync.Mutex
+	path     string
+	file     *os.File
+	records  map[string]*Record
+	appended int
+	now      func() time.Time
+}
+
+// Open replay the journal file and compact it, the file is created if not exist
+func Open(path string) (*Journal, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, err
+	}
+
+	j := &Journal{
+		path:    path,
+		records: make(map[string]*Record),
+		now:     time.Now,
+	}
+	if err := j.load(); err != nil {
+		return nil, err
+	}
+	if err := j.compact(); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// load the last snapshot of the transactions, the torn line written by the crash is skipped
+func (j *Journal) load() error {
+	file, err := os.Open(j.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			record := &Record{}
+			if json.Unmarshal(line, record) == nil && record.TransactionID != "" {
+				j.records[record.TransactionID] = record
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// compact rewrite the journal by the live records, the caller hold the lock or own the journal
+func (j *Journal) compact() error {
+	expired := j.now().Add(-recordRetention).Unix()
+	for id, record := range j.records {
+		if record.UpdatedAt < expired {
+			delete(j.records, id)
+		}
+	}
+
+	tmp := j.path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(file)
+	for _, record := range j.records {
+		line, err := json.Marshal(record)
+		if err != nil {
+			file.Close()
+			return err
+		}
+		if _, err := writer.Write(append(line, '\n')); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	if j.file != nil {
+		j.file.Close()
+		j.file = nil
+	}
+	if err := os.Rename(tmp, j.path); err != nil {
+		return err
+	}
+
+	j.file, err = os.OpenFile(j.path, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	j.appended = len(j.records)
+	return nil
+}
+
+// append write the snapshot of the record and wait it is on disk
+func (j *Journal) append(record *Record) error {
+	if j.file == nil {
+		return ErrJournalClosed
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if _, err := j.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	if err := j.file.Sync(); err != nil {
+		return err
+	}
+
+	j.records[record.TransactionID] = record
+	j.appended++
+	if j.appended > compactThreshold && j.appended > 2*len(j.records) {
+		return j.compact()
+	}
+	return nil
+}
+
+// Get the journaled transaction
+func (j *Journal) Get(id string) (*Record, bool) {
+	if j == nil {
+		return nil, false
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	record, ok := j.records[id]
+	if !ok {
+		return nil, false
+	}
+	return record.copy(), true
+}
+
+// Transit journal the update of the transaction before it is sent to the proxy,
+// the input is the payload the update is handled from
+func (j *Journal) Transit(name string, update *sphinxproxy.UpdateTransactionRequest, input []byte) error {
+	if j == nil {
+		return nil
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	id := update.GetTransactionID()
+	record, ok := j.records[id]
+	if ok {
+		record = record.copy()
+	} else {
+		record = &Record{TransactionID: id}
+	}
+
+	now := j.now().Unix()
+	record.Name = name
+	record.State = update.GetTransactionState()
+	record.NextState = update.GetNextTransactionState()
+	record.Input = Digest(input)
+	record.Payload = update.GetPayload()
+	record.CID = update.GetCID()
+	record.ExitCode = update.GetExitCode()
+	record.Synced = false
+	record.UpdatedAt = now
+	record.History = append(record.History, Transition{
+		State:     record.State,
+		NextState: record.NextState,
+		At:        now,
+	})
+
+	switch {
+	case record.State == sphinxproxy.TransactionState_TransactionStateWait &&
+		record.NextState == sphinxproxy.TransactionState_TransactionStateSign:
+		record.PreSign = record.Payload
+	case record.State == sphinxproxy.TransactionState_TransactionStateBroadcast:
+		record.Signed = input
+	}
+	if record.CID != "" {
+		record.TxID = record.CID
+	}
+
+	return j.append(record)
+}
+
+// Synced mark the last update of the transaction is accepted by the proxy
+func (j *Journal) Synced(id string) error {
+	if j == nil {
+		return nil
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	record, ok := j.records[id]
+	if !ok || record.Synced {
+		return nil
+	}
+	record = record.copy()
+	record.Synced = true
+	record.UpdatedAt = j.now().Unix()
+
+	return j.append(record)
+}
+
+// Unsynced the transactions which the last update is not accepted by the proxy
+func (j *Journal) Unsynced() []*Record {
+	if j == nil {
+		return nil
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	records := []*Record{}
+	for _, record := range j.records {
+		if !record.Synced {
+			records = append(records, record.copy())
+		}
+	}
+	return records
+}
+
+// Close the journal file
+func (j *Journal) Close() error {
+	if j == nil {
+		return nil
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.file == nil {
+		return nil
+	}
+	err := j.file.Close()
+	j.file = nil
+	return err
+}
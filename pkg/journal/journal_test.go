@@ -0,0 +1,75 @@
+package journal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/NpoolPlatform/message/npool/sphinxproxy"
+	"github.com/test-go/testify/assert"
+)
+
+func TestJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal", "sphinx-plugin.journal")
+	j, err := Open(path)
+	assert.Nil(t, err)
+
+	assert.Nil(t, j.Transit("tethereum", &sphinxproxy.UpdateTransactionRequest{
+		TransactionID:        "a",
+		TransactionState:     sphinxproxy.TransactionState_TransactionStateWait,
+		NextTransactionState: sphinxproxy.TransactionState_TransactionStateSign,
+		Payload:              []byte("presign"),
+	}, nil))
+	assert.Nil(t, j.Synced("a"))
+	assert.Nil(t, j.Transit("tethereum", &sphinxproxy.UpdateTransactionRequest{
+		TransactionID:        "a",
+		TransactionState:     sphinxproxy.TransactionState_TransactionStateBroadcast,
+		NextTransactionState: sphinxproxy.TransactionState_TransactionStateSync,
+		CID:                  "0x01",
+		Payload:              []byte("broadcasted"),
+	}, []byte("signed")))
+	assert.Equal(t, 1, len(j.Unsynced()))
+	assert.Nil(t, j.Close())
+	assert.NotNil(t, j.Synced("a"))
+
+	// the torn line of the crash is skipped
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	assert.Nil(t, err)
+	_, err = file.WriteString(`{"transactionID":"b","sta`)
+	assert.Nil(t, err)
+	assert.Nil(t, file.Close())
+
+	j, err = Open(path)
+	assert.Nil(t, err)
+	defer j.Close()
+
+	record, ok := j.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, sphinxproxy.TransactionState_TransactionStateBroadcast, record.State)
+	assert.Equal(t, Digest([]byte("signed")), record.Input)
+	assert.Equal(t, []byte("presign"), record.PreSign)
+	assert.Equal(t, []byte("signed"), record.Signed)
+	assert.Equal(t, "0x01", record.TxID)
+	assert.False(t, record.Synced)
+	assert.Equal(t, 2, len(record.History))
+	assert.Equal(t, "0x01", record.Request().GetCID())
+	_, ok = j.Get("b")
+	assert.False(t, ok)
+
+	// the stale records are dropped by the compaction
+	j.now = func() time.Time { return time.Now().Add(recordRetention + time.Hour) }
+	assert.Nil(t, j.compact())
+	_, ok = j.Get("a")
+	assert.False(t, ok)
+}
+
+func TestDisabledJournal(t *testing.T) {
+	var j *Journal
+	assert.Nil(t, j.Transit("tethereum", &sphinxproxy.UpdateTransactionRequest{TransactionID: "a"}, nil))
+	assert.Nil(t, j.Synced("a"))
+	_, ok := j.Get("a")
+	assert.False(t, ok)
+	assert.Nil(t, j.Unsynced())
+	assert.Nil(t, j.Close())
+}
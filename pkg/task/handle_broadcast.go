@@ -18,6 +18,9 @@ import (
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/types"
 )
 
+// retry state of the failed broadcast transactions
+var broadcastRetries = newRetries()
+
 func init() {
 	// TODO: support from env or config dynamic set
 	if err := register("task::broadcast", 3*time.Second, broadcastWorker); err != nil {
@@ -27,6 +30,13 @@ func init() {
 
 func broadcastWorker(name string, interval time.Duration) {
 	log.Infof("%v start,dispatch interval time: %v", name, interval.String())
+	workers := newPool(
+		parallelism(func(info *config.ENVInfo) int { return info.BroadcastParallelism }),
+		updateTransactionsTimeout,
+		func(ctx context.Context, transInfo *sphinxproxy.TransactionInfo, pClient sphinxproxy.SphinxProxyClient) {
+			broadcast(ctx, name, transInfo, pClient)
+		},
+	)
 	for range time.NewTicker(interval).C {
 		func() {
 			conn, err := client.GetGRPCConn(config.GetENV().Proxy)
@@ -59,16 +69,15 @@ func broadcastWorker(name string, interval time.Duration) {
 				return
 			}
 
-			for _, transInfo := range transInfos.GetInfos() {
-				broadcast(ctx, name, transInfo, pClient)
-			}
+			workers.submit(transInfos.GetInfos(), pClient)
 		}()
 	}
 }
 
 func broadcast(ctx context.Context, name string, transInfo *sphinxproxy.TransactionInfo, pClient sphinxproxy.SphinxProxyClient) {
-	ctx, cancel := context.WithTimeout(ctx, updateTransactionsTimeout)
-	defer cancel()
+	if !broadcastRetries.ready(transInfo.GetTransactionID()) {
+		return
+	}
 
 	now := time.Now()
 	defer func() {
@@ -91,6 +100,11 @@ func broadcast(ctx context.Context, name string, transInfo *sphinxproxy.Transact
 		respPayload   []byte
 		err           error
 	)
+	// the update of the transaction is lost, send the journaled one again
+	if replayTransaction(ctx, name, tState, transInfo, pClient) {
+		return
+	}
+
 	tokenInfo = getter.GetTokenInfo(transInfo.GetName())
 	if tokenInfo == nil {
 		nextState = sphinxproxy.TransactionState_TransactionStateFail
@@ -111,6 +125,20 @@ func broadcast(ctx context.Context, name string, transInfo *sphinxproxy.Transact
 			err,
 		)
 		nextState = sphinxproxy.TransactionState_TransactionStateFail
+		respPayload = failPayload(abortInfo(tokenInfo.CoinType, err))
+		goto done
+	}
+
+	if failInfo := broadcastRetries.failed(transInfo.GetTransactionID(), err); failInfo != nil {
+		errorf(name,
+			"broadcase transaction: %v error: %v stop, retry budget exhausted after %v attempts",
+			transInfo.GetTransactionID(),
+			err,
+			failInfo.Attempts,
+		)
+		nextState = sphinxproxy.TransactionState_TransactionStateFail
+		failInfo.Code = coins_register.ErrorCode(tokenInfo.CoinType, err)
+		respPayload = failPayload(failInfo)
 		goto done
 	}
 
@@ -122,6 +150,7 @@ func broadcast(ctx context.Context, name string, transInfo *sphinxproxy.Transact
 
 	// TODO: delete this dirty code
 done:
+	broadcastRetries.done(transInfo.GetTransactionID())
 	{
 		if respPayload != nil {
 			if err := json.Unmarshal(respPayload, &broadcastInfo); err != nil {
@@ -130,16 +159,18 @@ done:
 		}
 	}
 
-	if _, err := pClient.UpdateTransaction(ctx, &sphinxproxy.UpdateTransactionRequest{
+	// the transaction expired, route the rebuilt one back to sign
+	if broadcastInfo.Resign != nil {
+		warnf(name, "broadcast transaction: %v expired, resign the rebuilt one", transInfo.GetTransactionID())
+		nextState = sphinxproxy.TransactionState_TransactionStateSign
+		respPayload = broadcastInfo.Resign
+	}
+
+	updateTransaction(ctx, name, transInfo, &sphinxproxy.UpdateTransactionRequest{
 		TransactionID:        transInfo.GetTransactionID(),
 		TransactionState:     tState,
 		NextTransactionState: nextState,
 		CID:                  broadcastInfo.TxID,
 		Payload:              respPayload,
-	}); err != nil {
-		errorf(name, "UpdateTransaction transaction: %v error: %v", transInfo.GetTransactionID(), err)
-		return
-	}
-
-	infof(name, "UpdateTransaction transaction: %v done", transInfo.GetTransactionID())
+	}, pClient)
 }
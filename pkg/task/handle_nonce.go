@@ -17,6 +17,9 @@ import (
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/types"
 )
 
+// retry state of the failed pre sign transactions
+var nonceRetries = newRetries()
+
 func init() {
 	// TODO: support from env or config dynamic set
 	if err := register("task::nonce", 3*time.Second, nonceWorker); err != nil {
@@ -26,6 +29,13 @@ func init() {
 
 func nonceWorker(name string, interval time.Duration) {
 	log.Infof("%v start,dispatch interval time: %v", name, interval.String())
+	workers := newPool(
+		parallelism(func(info *config.ENVInfo) int { return info.NonceParallelism }),
+		updateTransactionsTimeout,
+		func(ctx context.Context, transInfo *sphinxproxy.TransactionInfo, pClient sphinxproxy.SphinxProxyClient) {
+			nonce(ctx, name, transInfo, pClient)
+		},
+	)
 	for range time.NewTicker(interval).C {
 		func() {
 			conn, err := client.GetGRPCConn(config.GetENV().Proxy)
@@ -58,16 +68,15 @@ func nonceWorker(name string, interval time.Duration) {
 				return
 			}
 
-			for _, transInfo := range transInfos.GetInfos() {
-				nonce(ctx, name, transInfo, pClient)
-			}
+			workers.submit(transInfos.GetInfos(), pClient)
 		}()
 	}
 }
 
 func nonce(ctx context.Context, name string, transInfo *sphinxproxy.TransactionInfo, pClient sphinxproxy.SphinxProxyClient) {
-	ctx, cancel := context.WithTimeout(ctx, updateTransactionsTimeout)
-	defer cancel()
+	if !nonceRetries.ready(transInfo.GetTransactionID()) {
+		return
+	}
 
 	now := time.Now()
 	defer func() {
@@ -88,9 +97,15 @@ func nonce(ctx context.Context, name string, transInfo *sphinxproxy.TransactionI
 		handler        coins_register.HandlerDef
 		respPayload    []byte
 		preSignPayload []byte
+		amount         *types.Amount
 		err            error
 	)
 
+	// the update of the transaction is lost, send the journaled one again
+	if replayTransaction(ctx, name, tState, transInfo, pClient) {
+		return
+	}
+
 	tokenInfo = getter.GetTokenInfo(transInfo.GetName())
 	if tokenInfo == nil {
 		nextState = sphinxproxy.TransactionState_TransactionStateFail
@@ -103,12 +118,27 @@ func nonce(ctx context.Context, name string, transInfo *sphinxproxy.TransactionI
 		goto done
 	}
 
+	// the proxy only send the float amount, it is rounded to the token decimal
+	amount, err = types.NewAmountFromFloat(transInfo.GetAmount(), tokenInfo.Decimal)
+	if err != nil {
+		errorf(name,
+			"pre sign transaction: %v amount: %v error: %v stop",
+			transInfo.GetTransactionID(),
+			transInfo.GetAmount(),
+			err,
+		)
+		nextState = sphinxproxy.TransactionState_TransactionStateFail
+		respPayload = failPayload(abortInfo(tokenInfo.CoinType, err))
+		goto done
+	}
+
 	preSignPayload, err = json.Marshal(types.BaseInfo{
 		ENV:      tokenInfo.Net,
 		CoinType: tokenInfo.CoinType,
 		From:     transInfo.GetFrom(),
 		To:       transInfo.GetTo(),
 		Value:    transInfo.GetAmount(),
+		Amount:   amount.String(),
 	})
 	if err != nil {
 		errorf(name, "marshal presign info error: %v", err)
@@ -126,6 +156,20 @@ func nonce(ctx context.Context, name string, transInfo *sphinxproxy.TransactionI
 			err,
 		)
 		nextState = sphinxproxy.TransactionState_TransactionStateFail
+		respPayload = failPayload(abortInfo(tokenInfo.CoinType, err))
+		goto done
+	}
+
+	if failInfo := nonceRetries.failed(transInfo.GetTransactionID(), err); failInfo != nil {
+		errorf(name,
+			"pre sign transaction: %v error: %v stop, retry budget exhausted after %v attempts",
+			transInfo.GetTransactionID(),
+			err,
+			failInfo.Attempts,
+		)
+		nextState = sphinxproxy.TransactionState_TransactionStateFail
+		failInfo.Code = coins_register.ErrorCode(tokenInfo.CoinType, err)
+		respPayload = failPayload(failInfo)
 		goto done
 	}
 
@@ -137,15 +181,34 @@ func nonce(ctx context.Context, name string, transInfo *sphinxproxy.TransactionI
 	return
 
 done:
-	if _, err := pClient.UpdateTransaction(ctx, &sphinxproxy.UpdateTransactionRequest{
+	nonceRetries.done(transInfo.GetTransactionID())
+	if !updateTransaction(ctx, name, transInfo, &sphinxproxy.UpdateTransactionRequest{
 		TransactionID:        transInfo.GetTransactionID(),
 		TransactionState:     tState,
 		NextTransactionState: nextState,
 		Payload:              respPayload,
-	}); err != nil {
-		errorf(name, "UpdateTransaction transaction: %v error: %v", transInfo.GetTransactionID(), err)
+	}, pClient) && nextState == sphinxproxy.TransactionState_TransactionStateSign {
+		// the pre sign payload is lost, it is built again by the next pre sign
+		releasePreSign(ctx, name, transInfo, tokenInfo, respPayload)
+	}
+}
+
+// releasePreSign release the resource reserved by the lost pre sign payload, eg: the evm nonce
+func releasePreSign(
+	ctx context.Context,
+	name string,
+	transInfo *sphinxproxy.TransactionInfo,
+	tokenInfo *coins.TokenInfo,
+	preSignPayload []byte,
+) {
+	handler, err := getter.GetTokenHandler(tokenInfo.TokenType, coins_register.OpPreSignRelease)
+	if err != nil {
 		return
 	}
 
-	infof(name, "UpdateTransaction transaction: %v done", transInfo.GetTransactionID())
+	if _, err := handler(ctx, preSignPayload, tokenInfo); err != nil {
+		errorf(name, "release pre sign transaction: %v error: %v", transInfo.GetTransactionID(), err)
+		return
+	}
+	warnf(name, "pre sign transaction: %v is lost, release it", transInfo.GetTransactionID())
 }
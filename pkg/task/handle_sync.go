@@ -18,6 +18,9 @@ import (
 	"github.com/NpoolPlatform/sphinx-plugin/pkg/types"
 )
 
+// retry state of the failed sync transactions
+var syncRetries = newRetries()
+
 func init() {
 	if err := register(
 		"task::synctx",
@@ -47,6 +50,13 @@ func calcDuration() time.Duration {
 func syncTxWorker(name string, _interval time.Duration) {
 	interval := calcDuration()
 	log.Infof("%v start,dispatch interval time: %v", name, interval.String())
+	workers := newPool(
+		parallelism(func(info *config.ENVInfo) int { return info.SyncParallelism }),
+		updateTransactionsTimeout,
+		func(ctx context.Context, transInfo *sphinxproxy.TransactionInfo, pClient sphinxproxy.SphinxProxyClient) {
+			syncTx(ctx, name, transInfo, pClient)
+		},
+	)
 	for range time.NewTicker(interval).C {
 		func() {
 			conn, err := client.GetGRPCConn(config.GetENV().Proxy)
@@ -79,16 +89,15 @@ func syncTxWorker(name string, _interval time.Duration) {
 				return
 			}
 
-			for _, transInfo := range transInfos.GetInfos() {
-				syncTx(ctx, name, transInfo, pClient)
-			}
+			workers.submit(transInfos.GetInfos(), pClient)
 		}()
 	}
 }
 
 func syncTx(ctx context.Context, name string, transInfo *sphinxproxy.TransactionInfo, pClient sphinxproxy.SphinxProxyClient) {
-	ctx, cancel := context.WithTimeout(ctx, updateTransactionsTimeout)
-	defer cancel()
+	if !syncRetries.ready(transInfo.GetTransactionID()) {
+		return
+	}
 
 	now := time.Now()
 	defer func() {
@@ -112,6 +121,11 @@ func syncTx(ctx context.Context, name string, transInfo *sphinxproxy.Transaction
 		err         error
 	)
 
+	// the update of the transaction is lost, send the journaled one again
+	if replayTransaction(ctx, name, tState, transInfo, pClient) {
+		return
+	}
+
 	tokenInfo = getter.GetTokenInfo(transInfo.GetName())
 	if tokenInfo == nil {
 		nextState = sphinxproxy.TransactionState_TransactionStateFail
@@ -135,6 +149,29 @@ func syncTx(ctx context.Context, name string, transInfo *sphinxproxy.Transaction
 			err,
 		)
 		nextState = sphinxproxy.TransactionState_TransactionStateFail
+		respPayload = failPayload(abortInfo(tokenInfo.CoinType, err))
+		goto done
+	}
+
+	if waitOnChain(err) {
+		infof(name,
+			"sync transaction: %v error: %v wait",
+			transInfo.GetTransactionID(),
+			err,
+		)
+		return
+	}
+
+	if failInfo := syncRetries.failed(transInfo.GetTransactionID(), err); failInfo != nil {
+		errorf(name,
+			"sync transaction: %v error: %v stop, retry budget exhausted after %v attempts",
+			transInfo.GetTransactionID(),
+			err,
+			failInfo.Attempts,
+		)
+		nextState = sphinxproxy.TransactionState_TransactionStateFail
+		failInfo.Code = coins_register.ErrorCode(tokenInfo.CoinType, err)
+		respPayload = failPayload(failInfo)
 		goto done
 	}
 
@@ -147,6 +184,7 @@ func syncTx(ctx context.Context, name string, transInfo *sphinxproxy.Transaction
 
 	// TODO: delete this dirty code
 done:
+	syncRetries.done(transInfo.GetTransactionID())
 	{
 		if respPayload != nil {
 			if err := json.Unmarshal(respPayload, &syncInfo); err != nil {
@@ -156,16 +194,19 @@ done:
 		}
 	}
 
-	if _, err := pClient.UpdateTransaction(ctx, &sphinxproxy.UpdateTransactionRequest{
+	// the transaction expired or stuck, route the rebuilt one back to sign
+	if syncInfo.Resign != nil {
+		warnf(name, "sync transaction: %v expired or stuck, resign the rebuilt one", transInfo.GetTransactionID())
+		nextState = sphinxproxy.TransactionState_TransactionStateSign
+		respPayload = syncInfo.Resign
+	}
+
+	updateTransaction(ctx, name, transInfo, &sphinxproxy.UpdateTransactionRequest{
 		TransactionID:        transInfo.GetTransactionID(),
 		TransactionState:     tState,
 		NextTransactionState: nextState,
 		ExitCode:             syncInfo.ExitCode,
+		CID:                  syncInfo.TxID,
 		Payload:              respPayload,
-	}); err != nil {
-		errorf(name, "UpdateTransaction transaction: %v error: %v", transInfo.GetTransactionID(), err)
-		return
-	}
-
-	infof(name, "UpdateTransaction transaction: %v done", transInfo.GetTransactionID())
+	}, pClient)
 }
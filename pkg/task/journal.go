@@ -0,0 +1,118 @@
+package task
+
+import (
+	"context"
+
+	"github.com/NpoolPlatform/message/npool/sphinxproxy"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/client"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/config"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/journal"
+	pconst "github.com/NpoolPlatform/sphinx-plugin/pkg/message/const"
+)
+
+// the journal of the in-flight transactions, nil if the journal path is not set
+var txJournal *journal.Journal
+
+func openJournal() error {
+	envInfo := config.GetENV()
+	if envInfo == nil || envInfo.JournalPath == "" {
+		return nil
+	}
+
+	j, err := journal.Open(envInfo.JournalPath)
+	if err != nil {
+		return err
+	}
+	txJournal = j
+	return nil
+}
+
+// updateTransaction journal the update then send it to the proxy, the input is the
+// payload the update is handled from, false is returned when the update is neither
+// journaled nor accepted by the proxy, the payload of it is lost
+func updateTransaction(
+	ctx context.Context,
+	name string,
+	transInfo *sphinxproxy.TransactionInfo,
+	update *sphinxproxy.UpdateTransactionRequest,
+	pClient sphinxproxy.SphinxProxyClient,
+) bool {
+	journaled := txJournal != nil
+	if err := txJournal.Transit(transInfo.GetName(), update, transInfo.GetPayload()); err != nil {
+		errorf(name, "journal transaction: %v error: %v", update.GetTransactionID(), err)
+		journaled = false
+	}
+
+	if _, err := pClient.UpdateTransaction(ctx, update); err != nil {
+		errorf(name, "UpdateTransaction transaction: %v error: %v", update.GetTransactionID(), err)
+		return journaled
+	}
+
+	if err := txJournal.Synced(update.GetTransactionID()); err != nil {
+		errorf(name, "journal transaction: %v error: %v", update.GetTransactionID(), err)
+	}
+	infof(name, "UpdateTransaction transaction: %v done", update.GetTransactionID())
+	return true
+}
+
+// replayTransaction the proxy return the transaction in the state it is moved from by the
+// journaled update, the update is lost and sent again instead of handling it twice
+func replayTransaction(
+	ctx context.Context,
+	name string,
+	tState sphinxproxy.TransactionState,
+	transInfo *sphinxproxy.TransactionInfo,
+	pClient sphinxproxy.SphinxProxyClient,
+) bool {
+	record, ok := txJournal.Get(transInfo.GetTransactionID())
+	if !ok || record.State != tState || record.Input != journal.Digest(transInfo.GetPayload()) {
+		return false
+	}
+
+	warnf(name, "transaction: %v replay the journaled update %v -> %v",
+		record.TransactionID,
+		record.State,
+		record.NextState,
+	)
+	updateTransaction(ctx, name, transInfo, record.Request(), pClient)
+	return true
+}
+
+// reconcileJournal send the journaled updates which the proxy has not accepted
+// before the plugin restart, the update rejected by the proxy is replayed when the
+// proxy return the transaction again
+func reconcileJournal() {
+	const name = "task::journal"
+
+	records := txJournal.Unsynced()
+	if len(records) == 0 {
+		return
+	}
+
+	conn, err := client.GetGRPCConn(config.GetENV().Proxy)
+	if err != nil {
+		errorf(name, "call GetGRPCConn error: %v", err)
+		return
+	}
+	pClient := sphinxproxy.NewSphinxProxyClient(conn)
+
+	for _, record := range records {
+		func() {
+			ctx, cancel := context.WithTimeout(context.Background(), updateTransactionsTimeout)
+			defer cancel()
+
+			warnf(name, "transaction: %v reconcile the journaled update %v -> %v",
+				record.TransactionID,
+				record.State,
+				record.NextState,
+			)
+			if _, err := pClient.UpdateTransaction(pconst.SetPluginInfo(ctx), record.Request()); err != nil {
+				errorf(name, "UpdateTransaction transaction: %v error: %v", record.TransactionID, err)
+				return
+			}
+			if err := txJournal.Synced(record.TransactionID); err != nil {
+				errorf(name, "journal transaction: %v error: %v", record.TransactionID, err)
+			}
+		}()
+	}
+}
@@ -0,0 +1,88 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/NpoolPlatform/go-service-framework/pkg/logger"
+	"github.com/NpoolPlatform/message/npool/sphinxproxy"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/config"
+	"github.com/test-go/testify/assert"
+	"google.golang.org/grpc"
+)
+
+type fakeProxyClient struct {
+	sphinxproxy.SphinxProxyClient
+	err     error
+	updates []*sphinxproxy.UpdateTransactionRequest
+}
+
+func (c *fakeProxyClient) UpdateTransaction(
+	ctx context.Context,
+	in *sphinxproxy.UpdateTransactionRequest,
+	opts ...grpc.CallOption,
+) (*sphinxproxy.UpdateTransactionResponse, error) {
+	c.updates = append(c.updates, in)
+	return &sphinxproxy.UpdateTransactionResponse{}, c.err
+}
+
+func TestReplayTransaction(t *testing.T) {
+	assert.Nil(t, logger.Init(logger.DebugLevel, filepath.Join(t.TempDir(), "sphinx-plugin.log")))
+	config.SetENV(&config.ENVInfo{JournalPath: filepath.Join(t.TempDir(), "sphinx-plugin.journal")})
+	assert.Nil(t, openJournal())
+	defer func() {
+		assert.Nil(t, txJournal.Close())
+		txJournal = nil
+	}()
+
+	ctx := context.Background()
+	tState := sphinxproxy.TransactionState_TransactionStateBroadcast
+	transInfo := &sphinxproxy.TransactionInfo{TransactionID: "a", Name: "tethereum", Payload: []byte("signed")}
+	update := &sphinxproxy.UpdateTransactionRequest{
+		TransactionID:        "a",
+		TransactionState:     tState,
+		NextTransactionState: sphinxproxy.TransactionState_TransactionStateSync,
+		CID:                  "0x01",
+		Payload:              []byte("broadcasted"),
+	}
+
+	// the update is lost
+	pClient := &fakeProxyClient{err: errors.New("proxy unavailable")}
+	assert.False(t, replayTransaction(ctx, "test", tState, transInfo, pClient))
+	// the journaled update is not lost
+	assert.True(t, updateTransaction(ctx, "test", transInfo, update, pClient))
+	assert.Equal(t, 1, len(txJournal.Unsynced()))
+
+	// the transaction is returned again, the journaled update is sent instead of broadcast it again
+	pClient = &fakeProxyClient{}
+	assert.True(t, replayTransaction(ctx, "test", tState, transInfo, pClient))
+	assert.Equal(t, 1, len(pClient.updates))
+	assert.Equal(t, "0x01", pClient.updates[0].GetCID())
+	assert.Equal(t, []byte("broadcasted"), pClient.updates[0].GetPayload())
+	assert.Equal(t, 0, len(txJournal.Unsynced()))
+
+	// the resigned transaction is handled
+	resigned := &sphinxproxy.TransactionInfo{TransactionID: "a", Name: "tethereum", Payload: []byte("resigned")}
+	assert.False(t, replayTransaction(ctx, "test", tState, resigned, pClient))
+	assert.False(t, replayTransaction(ctx, "test", sphinxproxy.TransactionState_TransactionStateSync, transInfo, pClient))
+}
+
+func TestUpdateTransactionLost(t *testing.T) {
+	assert.Nil(t, logger.Init(logger.DebugLevel, filepath.Join(t.TempDir(), "sphinx-plugin.log")))
+	config.SetENV(&config.ENVInfo{})
+
+	ctx := context.Background()
+	transInfo := &sphinxproxy.TransactionInfo{TransactionID: "a", Name: "tethereum"}
+	update := &sphinxproxy.UpdateTransactionRequest{
+		TransactionID:        "a",
+		TransactionState:     sphinxproxy.TransactionState_TransactionStateWait,
+		NextTransactionState: sphinxproxy.TransactionState_TransactionStateSign,
+		Payload:              []byte("presign"),
+	}
+
+	// the journal is disabled, the update rejected by the proxy is lost
+	assert.False(t, updateTransaction(ctx, "test", transInfo, update, &fakeProxyClient{err: errors.New("proxy unavailable")}))
+	assert.True(t, updateTransaction(ctx, "test", transInfo, update, &fakeProxyClient{}))
+}
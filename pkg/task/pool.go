@@ -0,0 +1,108 @@
+package task
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/NpoolPlatform/message/npool/sphinxproxy"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/config"
+	pconst "github.com/NpoolPlatform/sphinx-plugin/pkg/message/const"
+)
+
+// default parallelism of the stage
+const defaultParallelism = 4
+
+type handleFunc func(ctx context.Context, transInfo *sphinxproxy.TransactionInfo, pClient sphinxproxy.SphinxProxyClient)
+
+type poolItem struct {
+	transInfo *sphinxproxy.TransactionInfo
+	pClient   sphinxproxy.SphinxProxyClient
+}
+
+// pool run the transactions of one stage concurrently, the transactions of the
+// same from address are run one by one in the order they are submitted, the
+// transaction which is queued or running is skipped when submitted again
+type pool struct {
+	timeout time.Duration
+	handle  handleFunc
+	sem     chan struct{}
+
+	mu sync.Mutex
+	// transaction id of the queued and running transactions
+	pending map[string]struct{}
+	// queued transactions of the from address which has a running goroutine
+	queues map[string][]*poolItem
+}
+
+func newPool(parallelism int, timeout time.Duration, handle handleFunc) *pool {
+	if parallelism <= 0 {
+		parallelism = defaultParallelism
+	}
+	return &pool{
+		timeout: timeout,
+		handle:  handle,
+		sem:     make(chan struct{}, parallelism),
+		pending: make(map[string]struct{}),
+		queues:  make(map[string][]*poolItem),
+	}
+}
+
+// submit queue the transactions and return immediately
+func (p *pool) submit(transInfos []*sphinxproxy.TransactionInfo, pClient sphinxproxy.SphinxProxyClient) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, transInfo := range transInfos {
+		id := transInfo.GetTransactionID()
+		if _, ok := p.pending[id]; ok {
+			continue
+		}
+		p.pending[id] = struct{}{}
+
+		from := transInfo.GetFrom()
+		queue, running := p.queues[from]
+		p.queues[from] = append(queue, &poolItem{transInfo: transInfo, pClient: pClient})
+		if !running {
+			go p.run(from)
+		}
+	}
+}
+
+// run the queued transactions of the from address until the queue is empty
+func (p *pool) run(from string) {
+	for {
+		p.mu.Lock()
+		queue := p.queues[from]
+		if len(queue) == 0 {
+			delete(p.queues, from)
+			p.mu.Unlock()
+			return
+		}
+		item := queue[0]
+		p.queues[from] = queue[1:]
+		p.mu.Unlock()
+
+		p.sem <- struct{}{}
+		p.runOne(item)
+		<-p.sem
+
+		p.mu.Lock()
+		delete(p.pending, item.transInfo.GetTransactionID())
+		p.mu.Unlock()
+	}
+}
+
+func (p *pool) runOne(item *poolItem) {
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+	p.handle(pconst.SetPluginInfo(ctx), item.transInfo, item.pClient)
+}
+
+// parallelism of the stage, the value is not set by the env use the default
+func parallelism(get func(info *config.ENVInfo) int) int {
+	if envInfo := config.GetENV(); envInfo != nil && get(envInfo) > 0 {
+		return get(envInfo)
+	}
+	return defaultParallelism
+}
@@ -0,0 +1,69 @@
+package task
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/NpoolPlatform/message/npool/sphinxproxy"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/config"
+	"github.com/test-go/testify/assert"
+)
+
+func TestPool(t *testing.T) {
+	config.SetENV(&config.ENVInfo{})
+
+	var (
+		mu       sync.Mutex
+		handled  = map[string][]string{}
+		running  int32
+		maxRun   int32
+		fromBusy sync.Map
+		wg       sync.WaitGroup
+	)
+
+	p := newPool(2, time.Second, func(ctx context.Context, transInfo *sphinxproxy.TransactionInfo, pClient sphinxproxy.SphinxProxyClient) {
+		defer wg.Done()
+		_, deadline := ctx.Deadline()
+		assert.True(t, deadline)
+
+		// the transactions of the same from are not run together
+		_, busy := fromBusy.LoadOrStore(transInfo.GetFrom(), true)
+		assert.False(t, busy)
+		defer fromBusy.Delete(transInfo.GetFrom())
+
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			m := atomic.LoadInt32(&maxRun)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRun, m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		handled[transInfo.GetFrom()] = append(handled[transInfo.GetFrom()], transInfo.GetTransactionID())
+		mu.Unlock()
+	})
+
+	infos := []*sphinxproxy.TransactionInfo{
+		{TransactionID: "a1", From: "a"},
+		{TransactionID: "a2", From: "a"},
+		{TransactionID: "b1", From: "b"},
+		{TransactionID: "c1", From: "c"},
+		{TransactionID: "a3", From: "a"},
+	}
+	wg.Add(len(infos))
+	p.submit(infos, nil)
+	// the overlapped tick is skipped
+	p.submit(infos, nil)
+	wg.Wait()
+
+	assert.Equal(t, []string{"a1", "a2", "a3"}, handled["a"])
+	assert.Equal(t, []string{"b1"}, handled["b"])
+	assert.Equal(t, []string{"c1"}, handled["c"])
+	assert.True(t, maxRun <= 2)
+}
@@ -90,6 +90,11 @@ func setUpTokens() error {
 }
 
 func Run() {
+	if err := openJournal(); err != nil {
+		panic(fmt.Errorf("fail to open journal: %v", err))
+	}
+	go reconcileJournal()
+
 	go func() {
 		err := setUpTokens()
 		if err != nil {
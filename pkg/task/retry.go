@@ -0,0 +1,137 @@
+package task
+
+import (
+	"encoding/json"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NpoolPlatform/message/npool/sphinxplugin"
+	coins_register "github.com/NpoolPlatform/sphinx-plugin/pkg/coins/register"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/config"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/env"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/types"
+)
+
+const (
+	// default max attempts of the failed transaction
+	defaultRetryMaxAttempts = 30
+	// the backoff is doubled by every failed attempt until the max
+	retryBaseBackoff = 3 * time.Second
+	retryMaxBackoff  = 10 * time.Minute
+	// the retry state of the transaction which is not failed again in it is dropped,
+	// the transaction may be handled by the other plugin
+	retryStaleAge = 24 * time.Hour
+)
+
+type retryState struct {
+	attempts  int
+	firstFail time.Time
+	lastFail  time.Time
+	next      time.Time
+}
+
+// retries the retry state of the failed transactions of one stage
+type retries struct {
+	mu     sync.Mutex
+	states map[string]*retryState
+	now    func() time.Time
+}
+
+func newRetries() *retries {
+	return &retries{
+		states: make(map[string]*retryState),
+		now:    time.Now,
+	}
+}
+
+// retryBudget the max attempts and the max age from the first failed attempt, 0 is unlimited
+func retryBudget() (int, time.Duration) {
+	envInfo := config.GetENV()
+	if envInfo == nil {
+		return defaultRetryMaxAttempts, 0
+	}
+	return envInfo.RetryMaxAttempts, time.Duration(envInfo.RetryMaxAge) * time.Second
+}
+
+// backoff exponential backoff with jitter, the result is in [d/2, d]
+func backoff(attempts int) time.Duration {
+	d := retryMaxBackoff
+	if shift := attempts - 1; shift < 20 && retryBaseBackoff<<shift < retryMaxBackoff {
+		d = retryBaseBackoff << shift
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// ready the backoff of the last failed attempt is elapsed
+func (r *retries) ready(id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	state, ok := r.states[id]
+	return !ok || !r.now().Before(state.next)
+}
+
+// failed record the failed attempt, the fail info is returned when the retry budget is exhausted
+func (r *retries) failed(id string, err error) *types.FailInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	for k, v := range r.states {
+		if now.Sub(v.lastFail) > retryStaleAge {
+			delete(r.states, k)
+		}
+	}
+
+	state, ok := r.states[id]
+	if !ok {
+		state = &retryState{firstFail: now}
+		r.states[id] = state
+	}
+	state.attempts++
+	state.lastFail = now
+	state.next = now.Add(backoff(state.attempts))
+
+	maxAttempts, maxAge := retryBudget()
+	if (maxAttempts > 0 && state.attempts >= maxAttempts) ||
+		(maxAge > 0 && now.Sub(state.firstFail) >= maxAge) {
+		delete(r.states, id)
+		return &types.FailInfo{
+			Error:       err.Error(),
+			Attempts:    state.attempts,
+			FirstFailAt: state.firstFail.Unix(),
+			LastFailAt:  now.Unix(),
+		}
+	}
+	return nil
+}
+
+// done forget the retry state of the transaction which is moved to the next state
+func (r *retries) done(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.states, id)
+}
+
+// waitOnChain the transaction is not on chain yet, it is not counted as a failed attempt
+func waitOnChain(err error) bool {
+	return err != nil && strings.Contains(err.Error(), env.ErrWaitMessageOnChain.Error())
+}
+
+// abortInfo the fail info of the transaction which is failed by the error at once
+func abortInfo(coinType sphinxplugin.CoinType, err error) *types.FailInfo {
+	return &types.FailInfo{
+		Code:  coins_register.ErrorCode(coinType, err),
+		Error: err.Error(),
+	}
+}
+
+func failPayload(failInfo *types.FailInfo) []byte {
+	payload, err := json.Marshal(failInfo)
+	if err != nil {
+		return nil
+	}
+	return payload
+}
@@ -0,0 +1,114 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/NpoolPlatform/go-service-framework/pkg/logger"
+	"github.com/NpoolPlatform/message/npool/sphinxproxy"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/coins"
+	coins_register "github.com/NpoolPlatform/sphinx-plugin/pkg/coins/register"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/config"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/env"
+	"github.com/NpoolPlatform/sphinx-plugin/pkg/types"
+	"github.com/test-go/testify/assert"
+)
+
+func TestBackoff(t *testing.T) {
+	for attempts := 1; attempts < 100; attempts++ {
+		d := backoff(attempts)
+		max := retryMaxBackoff
+		if attempts < 10 && retryBaseBackoff<<(attempts-1) < max {
+			max = retryBaseBackoff << (attempts - 1)
+		}
+		assert.True(t, d >= max/2 && d <= max, fmt.Sprintf("attempts %v backoff %v", attempts, d))
+	}
+}
+
+func TestRetries(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	r := newRetries()
+	r.now = func() time.Time { return now }
+	err := errors.New("rpc timeout")
+
+	config.SetENV(&config.ENVInfo{RetryMaxAttempts: 3})
+	assert.True(t, r.ready("a"))
+	assert.Nil(t, r.failed("a", err))
+	assert.False(t, r.ready("a"))
+	assert.True(t, r.ready("b"))
+
+	now = now.Add(retryMaxBackoff)
+	assert.True(t, r.ready("a"))
+	assert.Nil(t, r.failed("a", err))
+
+	now = now.Add(retryMaxBackoff)
+	failInfo := r.failed("a", err)
+	assert.NotNil(t, failInfo)
+	assert.Equal(t, err.Error(), failInfo.Error)
+	assert.Equal(t, 3, failInfo.Attempts)
+	assert.Equal(t, int64(1_700_000_000), failInfo.FirstFailAt)
+	assert.True(t, r.ready("a"))
+
+	// max age
+	config.SetENV(&config.ENVInfo{RetryMaxAge: 60})
+	assert.Nil(t, r.failed("c", err))
+	now = now.Add(time.Minute)
+	assert.NotNil(t, r.failed("c", err))
+
+	// done forget the state
+	assert.Nil(t, r.failed("d", err))
+	r.done("d")
+	assert.True(t, r.ready("d"))
+
+	assert.True(t, waitOnChain(env.ErrWaitMessageOnChain))
+	assert.True(t, waitOnChain(errors.New("wait message on chain min confirms")))
+	assert.False(t, waitOnChain(err))
+	assert.False(t, waitOnChain(nil))
+}
+
+func TestSyncRetryBudget(t *testing.T) {
+	assert.Nil(t, logger.Init(logger.DebugLevel, filepath.Join(t.TempDir(), "sphinx-plugin.log")))
+	config.SetENV(&config.ENVInfo{RetryMaxAttempts: 2})
+	defer config.SetENV(nil)
+
+	now := time.Unix(1_700_000_000, 0)
+	syncRetries.now = func() time.Time { return now }
+	defer func() { syncRetries.now = time.Now }()
+
+	tokenType := coins.TokenType("retrybudget")
+	tokenInfo := &coins.TokenInfo{Name: "tretrybudget", TokenType: tokenType, Net: coins.CoinNetTest}
+	coins_register.NameToTokenInfo[tokenInfo.Name] = tokenInfo
+	defer delete(coins_register.NameToTokenInfo, tokenInfo.Name)
+	coins_register.RegisteTokenHandler(tokenType, coins_register.OpSyncTx, func(ctx context.Context, in []byte, tokenInfo *coins.TokenInfo) ([]byte, error) {
+		return nil, errors.New("rpc timeout")
+	})
+	defer delete(coins_register.TokenHandlers, tokenType)
+
+	ctx := context.Background()
+	transInfo := &sphinxproxy.TransactionInfo{
+		TransactionID:    "a",
+		Name:             tokenInfo.Name,
+		TransactionState: sphinxproxy.TransactionState_TransactionStateSync,
+	}
+	pClient := &fakeProxyClient{}
+	syncTx(ctx, "test", transInfo, pClient)
+	assert.Equal(t, 0, len(pClient.updates))
+
+	// the transaction is failed with the last error once the retry budget is exhausted
+	now = now.Add(retryMaxBackoff)
+	syncTx(ctx, "test", transInfo, pClient)
+	assert.Equal(t, 1, len(pClient.updates))
+	update := pClient.updates[0]
+	assert.Equal(t, sphinxproxy.TransactionState_TransactionStateFail, update.GetNextTransactionState())
+
+	failInfo := types.FailInfo{}
+	assert.Nil(t, json.Unmarshal(update.GetPayload(), &failInfo))
+	assert.Equal(t, "rpc timeout", failInfo.Error)
+	assert.Equal(t, 2, failInfo.Attempts)
+	assert.True(t, syncRetries.ready(transInfo.GetTransactionID()))
+}
@@ -236,12 +236,13 @@ func (c *pluginClient) recv() {
 				// handler, err := coins.GetCoinBalancePlugin(coinType, transactionType)
 				tokenInfo := getter.GetTokenInfo(req.Name)
 				if tokenInfo == nil {
+					err = fmt.Errorf("%v, %v", coins_register.ErrCoinTypeNotFound, req.Name)
 					log.Errorf("GetCoinPlugin get handler error: %v", err)
 					resp = &sphinxproxy.ProxyPluginResponse{
 						TransactionType: req.GetTransactionType(),
 						CoinType:        req.GetCoinType(),
 						TransactionID:   req.GetTransactionID(),
-						RPCExitMessage:  err.Error(),
+						RPCExitMessage:  coins_register.ExitMessage(coinType, err),
 					}
 					goto send
 				}
@@ -259,7 +260,7 @@ func (c *pluginClient) recv() {
 						TransactionType: req.GetTransactionType(),
 						CoinType:        req.GetCoinType(),
 						TransactionID:   req.GetTransactionID(),
-						RPCExitMessage:  err.Error(),
+						RPCExitMessage:  coins_register.ExitMessage(coinType, err),
 					}
 					goto send
 				}
@@ -271,7 +272,7 @@ func (c *pluginClient) recv() {
 							TransactionType: req.GetTransactionType(),
 							CoinType:        req.GetCoinType(),
 							TransactionID:   req.GetTransactionID(),
-							RPCExitMessage:  err.Error(),
+							RPCExitMessage:  coins_register.ExitMessage(coinType, err),
 						}
 						goto send
 					}
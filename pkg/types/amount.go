@@ -0,0 +1,108 @@
+package types
+
+import (
+	"errors"
+	"math"
+	"math/big"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrAmountInvalid   = errors.New("amount invalid")
+	ErrAmountPrecision = errors.New("amount precision exceeds token decimal")
+)
+
+// Amount is an exact token amount, it is stored in the chain atomic unit
+// (wei, satoshi, sun, lamport ...) together with the token decimal
+type Amount struct {
+	atomic  *big.Int
+	decimal int
+}
+
+// NewAmountFromAtomic build amount from the chain atomic unit
+func NewAmountFromAtomic(atomic *big.Int, _decimal int) *Amount {
+	if atomic == nil {
+		atomic = big.NewInt(0)
+	}
+	return &Amount{
+		atomic:  new(big.Int).Set(atomic),
+		decimal: _decimal,
+	}
+}
+
+// NewAmountFromString build amount from the token unit, eg: 1.5 ETH
+// the value must not carry more fraction digits than the token decimal
+func NewAmountFromString(value string, _decimal int) (*Amount, error) {
+	d, err := decimal.NewFromString(value)
+	if err != nil {
+		return nil, ErrAmountInvalid
+	}
+	return newAmountFromDecimal(d, _decimal)
+}
+
+// NewAmountFromFloat build amount from the token unit
+// the float is converted by its shortest decimal representation, so 0.1 is exactly 0.1,
+// the float can not be exact, eg: 0.1+0.2, it is truncated to the token decimal, so
+// the amount is never more than the requested
+func NewAmountFromFloat(value float64, _decimal int) (*Amount, error) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return nil, ErrAmountInvalid
+	}
+	return newAmountFromDecimal(decimal.NewFromFloat(value).Truncate(int32(_decimal)), _decimal)
+}
+
+// ParseAmount prefer the exact amount string, fallback to the float value for
+// the payloads which built by old version, only the string amount is checked by
+// the token decimal
+func ParseAmount(amount string, value float64, _decimal int) (*Amount, error) {
+	if amount != "" {
+		return NewAmountFromString(amount, _decimal)
+	}
+	return NewAmountFromFloat(value, _decimal)
+}
+
+func newAmountFromDecimal(d decimal.Decimal, _decimal int) (*Amount, error) {
+	if _decimal < 0 {
+		return nil, ErrAmountInvalid
+	}
+	shifted := d.Shift(int32(_decimal))
+	if !shifted.Equal(shifted.Truncate(0)) {
+		return nil, ErrAmountPrecision
+	}
+	return &Amount{
+		atomic:  shifted.BigInt(),
+		decimal: _decimal,
+	}, nil
+}
+
+// BigInt return a copy of the amount in the chain atomic unit
+func (a *Amount) BigInt() *big.Int {
+	return new(big.Int).Set(a.atomic)
+}
+
+// Decimal return the exact amount in the token unit
+func (a *Amount) Decimal() decimal.Decimal {
+	return decimal.NewFromBigInt(a.atomic, -int32(a.decimal))
+}
+
+// Float64 only for the compatible float fields, it may lose accuracy
+func (a *Amount) Float64() float64 {
+	return a.Decimal().InexactFloat64()
+}
+
+func (a *Amount) Sign() int {
+	return a.atomic.Sign()
+}
+
+func (a *Amount) String() string {
+	return a.Decimal().String()
+}
+
+// NewWalletBalanceResponse fill the compatible float balance and the exact balance string
+func NewWalletBalanceResponse(balance *Amount) *WalletBalanceResponse {
+	return &WalletBalanceResponse{
+		Balance:    balance.Float64(),
+		BalanceStr: balance.String(),
+	}
+}
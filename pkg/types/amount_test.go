@@ -0,0 +1,33 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/test-go/testify/assert"
+)
+
+func TestParseAmount(t *testing.T) {
+	// the legacy float is truncated to the token decimal
+	amount, err := ParseAmount("", 0.1+0.2, 6)
+	assert.Nil(t, err)
+	assert.Equal(t, "0.3", amount.String())
+	assert.Equal(t, int64(300_000), amount.BigInt().Int64())
+
+	amount, err = ParseAmount("", 1.23456789, 6)
+	assert.Nil(t, err)
+	assert.Equal(t, "1.234567", amount.String())
+
+	amount, err = ParseAmount("", 0.9999999, 6)
+	assert.Nil(t, err)
+	assert.Equal(t, "0.999999", amount.String())
+
+	// the explicit string amount must be exact
+	_, err = ParseAmount("1.23456789", 0, 6)
+	assert.Equal(t, ErrAmountPrecision, err)
+	amount, err = ParseAmount("1.234567", 1.23456789, 6)
+	assert.Nil(t, err)
+	assert.Equal(t, "1.234567", amount.String())
+
+	_, err = ParseAmount("abc", 0, 6)
+	assert.Equal(t, ErrAmountInvalid, err)
+}
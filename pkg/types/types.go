@@ -23,11 +23,28 @@ type BaseInfo struct {
 	CoinType sphinxplugin.CoinType `json:"coin_type"`
 	From     string                `json:"from"`
 	To       string                `json:"to"`
-	Value    float64               `json:"value"`
+	// Value only for compatible, use Amount to get the exact value
+	Value float64 `json:"value"`
+	// Amount exact value in token unit
+	Amount string `json:"amount,omitempty"`
+}
+
+// GetAmount parse the exact transfer amount by the token decimal
+func (info *BaseInfo) GetAmount(_decimal int) (*Amount, error) {
+	return ParseAmount(info.Amount, info.Value, _decimal)
 }
 
 type BroadcastInfo struct {
 	TxID string `json:"tx_id"`
+	// the signed transaction, sync rebuild it with the bumped fee when it is stuck
+	SignedTx []byte `json:"signed_tx,omitempty"`
+	// the same nonce transactions which replaced by TxID, the oldest is the first
+	ReplacedTxIDs []string `json:"replaced_tx_ids,omitempty"`
+	// chain height and unix time when broadcast, used to check the stuck transaction
+	BlockNum    uint64 `json:"block_num,omitempty"`
+	BroadcastAt int64  `json:"broadcast_at,omitempty"`
+	// the pre sign payload of the rebuilt transaction, the expired one should be signed and broadcast again
+	Resign []byte `json:"resign,omitempty"`
 }
 
 type SyncRequest struct {
@@ -36,6 +53,21 @@ type SyncRequest struct {
 
 type SyncResponse struct {
 	ExitCode int64 `json:"exit_code"`
+	// the tx id which on chain, it may be one of the replaced transactions
+	TxID string `json:"tx_id,omitempty"`
+	// the pre sign payload of the rebuilt transaction, the expired or stuck one should be signed and broadcast again
+	Resign []byte `json:"resign,omitempty"`
+}
+
+// FailInfo the payload of the failed transaction, the code is the kind of the error
+type FailInfo struct {
+	Code  string `json:"code"`
+	Error string `json:"error"`
+	// the failed attempts and the unix time of the first and the last one, they
+	// are set when the retry budget is exhausted
+	Attempts    int   `json:"attempts,omitempty"`
+	FirstFailAt int64 `json:"first_fail_at,omitempty"`
+	LastFailAt  int64 `json:"last_fail_at,omitempty"`
 }
 
 // plugin